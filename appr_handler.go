@@ -2,6 +2,7 @@ package diff
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"reflect"
 )
@@ -17,6 +18,11 @@ type ApprHandler struct {
 	Resource    string
 	Action1     string
 	Action2     string
+	Status      StatusConfig
+	// CommentService, when set, serves Comment
+	CommentService CommentService
+	// ChangeRequestService, when set, serves RequestChanges
+	ChangeRequestService ChangeRequestService
 }
 
 func NewApprHandler(apprService ApprService, modelType reflect.Type, logError func(context.Context, string), option ...int) *ApprHandler {
@@ -60,7 +66,7 @@ func NewApprHandlerWithKeysAndLog(apprService ApprService, keys []string, modelT
 	} else {
 		resource = BuildResourceName(modelType.Name())
 	}
-	return &ApprHandler{Log: writeLog, ApprService: apprService, ModelType: modelType, Keys: keys, Indexes: indexes, Offset: offset, Error: logError, Resource: resource, Action1: action1, Action2: action2}
+	return &ApprHandler{Log: writeLog, ApprService: apprService, ModelType: modelType, Keys: keys, Indexes: indexes, Offset: offset, Error: logError, Resource: resource, Action1: action1, Action2: action2, Status: GetServiceStatus(apprService)}
 }
 
 func (c *ApprHandler) Approve(w http.ResponseWriter, r *http.Request) {
@@ -68,11 +74,19 @@ func (c *ApprHandler) Approve(w http.ResponseWriter, r *http.Request) {
 	if er1 != nil {
 		http.Error(w, er1.Error(), http.StatusBadRequest)
 	} else {
-		result, er2 := c.ApprService.Approve(r.Context(), id)
-		if er2 != nil {
+		review, er3 := DecodeReview(r)
+		if er3 != nil {
+			http.Error(w, er3.Error(), http.StatusBadRequest)
+			return
+		}
+		result, er2 := c.ApprService.Approve(WithReview(r.Context(), review), id)
+		var conflict *VersionConflict
+		if errors.As(er2, &conflict) {
+			respond(w, r, http.StatusConflict, conflict, c.Log, c.Resource, c.Action1, false, er2.Error())
+		} else if er2 != nil {
 			handleError(w, r, http.StatusOK, internalServerError, c.Error, c.Resource, c.Action1, er2, c.Log)
 		} else {
-			succeed(w, r, http.StatusOK, result, c.Log, c.Resource, c.Action1)
+			succeed(w, r, GetReviewCode(c.Status, result), result, c.Log, c.Resource, c.Action1)
 		}
 	}
 }
@@ -82,11 +96,68 @@ func (c *ApprHandler) Reject(w http.ResponseWriter, r *http.Request) {
 	if er1 != nil {
 		http.Error(w, er1.Error(), http.StatusBadRequest)
 	} else {
-		result, er2 := c.ApprService.Reject(r.Context(), id)
+		review, er3 := DecodeReview(r)
+		if er3 != nil {
+			http.Error(w, er3.Error(), http.StatusBadRequest)
+			return
+		}
+		result, er2 := c.ApprService.Reject(WithReview(r.Context(), review), id)
 		if er2 != nil {
 			handleError(w, r, http.StatusOK, internalServerError, c.Error, c.Resource, c.Action2, er2, c.Log)
 		} else {
-			succeed(w, r, http.StatusOK, result, c.Log, c.Resource, c.Action2)
+			succeed(w, r, GetReviewCode(c.Status, result), result, c.Log, c.Resource, c.Action2)
 		}
 	}
 }
+
+// RequestChanges sends a staged change back to its author; the optional body is the review, as for Reject.
+func (c *ApprHandler) RequestChanges(w http.ResponseWriter, r *http.Request) {
+	if c.ChangeRequestService == nil {
+		http.Error(w, "RequestChanges is not supported", http.StatusNotImplemented)
+		return
+	}
+	id, er1 := BuildId(r, c.ModelType, c.Keys, c.Indexes, c.Offset)
+	if er1 != nil {
+		http.Error(w, er1.Error(), http.StatusBadRequest)
+		return
+	}
+	review, er2 := DecodeReview(r)
+	if er2 != nil {
+		http.Error(w, er2.Error(), http.StatusBadRequest)
+		return
+	}
+	result, er3 := c.ChangeRequestService.RequestChanges(WithReview(r.Context(), review), id)
+	if er3 != nil {
+		handleError(w, r, http.StatusOK, internalServerError, c.Error, c.Resource, "request_changes", er3, c.Log)
+	} else {
+		succeed(w, r, GetReviewCode(c.Status, result), result, c.Log, c.Resource, "request_changes")
+	}
+}
+
+// Comment adds a comment to the thread of a staged change; the body is {"comment": "..."}.
+func (c *ApprHandler) Comment(w http.ResponseWriter, r *http.Request) {
+	if c.CommentService == nil {
+		http.Error(w, "Comment is not supported", http.StatusNotImplemented)
+		return
+	}
+	id, er1 := BuildId(r, c.ModelType, c.Keys, c.Indexes, c.Offset)
+	if er1 != nil {
+		http.Error(w, er1.Error(), http.StatusBadRequest)
+		return
+	}
+	review, er2 := DecodeReview(r)
+	if er2 != nil {
+		http.Error(w, er2.Error(), http.StatusBadRequest)
+		return
+	}
+	if review == nil || len(review.Comment) == 0 {
+		http.Error(w, "comment is required", http.StatusBadRequest)
+		return
+	}
+	result, er3 := c.CommentService.Comment(r.Context(), id, review.Comment)
+	if er3 != nil {
+		handleError(w, r, http.StatusOK, internalServerError, c.Error, c.Resource, "comment", er3, c.Log)
+	} else {
+		succeed(w, r, GetReviewCode(c.Status, result), result, c.Log, c.Resource, "comment")
+	}
+}
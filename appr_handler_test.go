@@ -0,0 +1,99 @@
+package diff_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	d "github.com/core-go/diff"
+)
+
+type commentFunc func(ctx context.Context, id interface{}, text string) (int, error)
+
+func (f commentFunc) Comment(ctx context.Context, id interface{}, text string) (int, error) {
+	return f(ctx, id, text)
+}
+
+type apprFunc func(ctx context.Context, id interface{}) (int, error)
+
+func (f apprFunc) Approve(ctx context.Context, id interface{}) (int, error) {
+	return f(ctx, id)
+}
+
+func (f apprFunc) Reject(ctx context.Context, id interface{}) (int, error) {
+	return f(ctx, id)
+}
+
+func (f apprFunc) RequestChanges(ctx context.Context, id interface{}) (int, error) {
+	return f(ctx, id)
+}
+
+func TestApprHandlerCommentAndRequestChanges(t *testing.T) {
+	store := d.NewMemoryStore("users", []string{"id"}, nil, d.NewDefaultKeyBuilder(), nil)
+	handler := d.NewApprHandler(store, reflect.TypeOf(User{}), nil)
+	serve := func(h http.HandlerFunc, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h(w, httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{"comment":"why?"}`)))
+		return w
+	}
+	if w := serve(handler.Comment, "/users/u1/comment"); w.Code != http.StatusNotImplemented {
+		t.Errorf("comment without CommentService: %d, want %d", w.Code, http.StatusNotImplemented)
+	}
+	if w := serve(handler.RequestChanges, "/users/u1/request-changes"); w.Code != http.StatusNotImplemented {
+		t.Errorf("request changes without ChangeRequestService: %d, want %d", w.Code, http.StatusNotImplemented)
+	}
+	handler.CommentService = commentFunc(func(ctx context.Context, id interface{}, text string) (int, error) {
+		return store.Status.Error, errors.New("database is down")
+	})
+	if w := serve(handler.Comment, "/users/u1/comment"); w.Code != http.StatusOK {
+		t.Errorf("failed comment: %d, want %d as for Approve and Reject", w.Code, http.StatusOK)
+	}
+}
+
+func TestApprHandlerMapsTheResultOfEveryReviewAction(t *testing.T) {
+	status := d.InitializeStatus(nil)
+	tests := []struct {
+		name   string
+		result int
+		code   int
+	}{
+		{"success", status.Success, http.StatusOK},
+		{"not found", status.NotFound, http.StatusNotFound},
+		{"forbidden", status.Forbidden, http.StatusForbidden},
+		{"version error", status.VersionError, http.StatusConflict},
+	}
+	for _, test := range tests {
+		result := test.result
+		service := apprFunc(func(ctx context.Context, id interface{}) (int, error) {
+			return result, nil
+		})
+		handler := d.NewApprHandler(service, reflect.TypeOf(User{}), nil)
+		handler.ChangeRequestService = service
+		handler.CommentService = commentFunc(func(ctx context.Context, id interface{}, text string) (int, error) {
+			return result, nil
+		})
+		actions := map[string]http.HandlerFunc{"approve": handler.Approve, "reject": handler.Reject, "request-changes": handler.RequestChanges, "comment": handler.Comment}
+		for action, h := range actions {
+			w := httptest.NewRecorder()
+			h(w, httptest.NewRequest(http.MethodPost, "/users/u1/"+action, strings.NewReader(`{"comment":"why?"}`)))
+			if w.Code != test.code {
+				t.Errorf("%s of %s: %d, want %d", action, test.name, w.Code, test.code)
+			}
+		}
+	}
+}
+
+func TestApprHandlerAnswersConflictsWithTheConflict(t *testing.T) {
+	service := apprFunc(func(ctx context.Context, id interface{}) (int, error) {
+		return d.InitializeStatus(nil).VersionError, &d.VersionConflict{Id: "u1", Current: map[string]interface{}{"name": "B"}}
+	})
+	w := httptest.NewRecorder()
+	d.NewApprHandler(service, reflect.TypeOf(User{}), nil).Approve(w, httptest.NewRequest(http.MethodPost, "/users/u1/approve", nil))
+	if w.Code != http.StatusConflict || !strings.Contains(w.Body.String(), `"current":{"name":"B"}`) {
+		t.Errorf("approve of a conflict: %d %s, want %d and the current row", w.Code, w.Body.String(), http.StatusConflict)
+	}
+}
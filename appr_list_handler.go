@@ -71,3 +71,43 @@ func (c *ApprListHandler) Reject(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 }
+
+// ApproveList responds the result of each id, including the version conflicts; it needs a service implementing ApprListResultService.
+func (c *ApprListHandler) ApproveList(w http.ResponseWriter, r *http.Request) {
+	s, ok := c.ApprListService.(ApprListResultService)
+	if !ok {
+		http.Error(w, "ApproveList is not supported", http.StatusNotImplemented)
+		return
+	}
+	ids, er1 := BuildIds(r, c.ModelType, c.Keys)
+	if er1 != nil {
+		http.Error(w, er1.Error(), http.StatusBadRequest)
+		return
+	}
+	results, er2 := s.ApproveList(r.Context(), ids)
+	if er2 != nil {
+		handleError(w, r, http.StatusInternalServerError, internalServerError, c.Error, c.Resource, c.Action1, er2, c.Log)
+	} else {
+		succeed(w, r, http.StatusOK, results, c.Log, c.Resource, c.Action1)
+	}
+}
+
+// RejectList responds the result of each id, including the version conflicts; it needs a service implementing ApprListResultService.
+func (c *ApprListHandler) RejectList(w http.ResponseWriter, r *http.Request) {
+	s, ok := c.ApprListService.(ApprListResultService)
+	if !ok {
+		http.Error(w, "RejectList is not supported", http.StatusNotImplemented)
+		return
+	}
+	ids, er1 := BuildIds(r, c.ModelType, c.Keys)
+	if er1 != nil {
+		http.Error(w, er1.Error(), http.StatusBadRequest)
+		return
+	}
+	results, er2 := s.RejectList(r.Context(), ids)
+	if er2 != nil {
+		handleError(w, r, http.StatusInternalServerError, internalServerError, c.Error, c.Resource, c.Action2, er2, c.Log)
+	} else {
+		succeed(w, r, http.StatusOK, results, c.Log, c.Resource, c.Action2)
+	}
+}
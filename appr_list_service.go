@@ -2,7 +2,19 @@ package diff
 
 import "context"
 
+type ApprResult struct {
+	Id       interface{}      `yaml:"id" mapstructure:"id" json:"id,omitempty" gorm:"column:id" bson:"_id,omitempty" dynamodbav:"id,omitempty" firestore:"id,omitempty"`
+	Status   int              `yaml:"status" mapstructure:"status" json:"status" gorm:"column:status" bson:"status" dynamodbav:"status" firestore:"status"`
+	Error    string           `yaml:"error" mapstructure:"error" json:"error,omitempty" gorm:"column:error" bson:"error,omitempty" dynamodbav:"error,omitempty" firestore:"error,omitempty"`
+	Conflict *VersionConflict `yaml:"conflict" mapstructure:"conflict" json:"conflict,omitempty" gorm:"-" bson:"conflict,omitempty" dynamodbav:"conflict,omitempty" firestore:"conflict,omitempty"`
+}
+
 type ApprListService interface {
 	Approve(ctx context.Context, ids interface{}) (int, error)
 	Reject(ctx context.Context, ids interface{}) (int, error)
 }
+
+type ApprListResultService interface {
+	ApproveList(ctx context.Context, ids interface{}) ([]ApprResult, error)
+	RejectList(ctx context.Context, ids interface{}) ([]ApprResult, error)
+}
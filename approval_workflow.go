@@ -0,0 +1,39 @@
+package diff
+
+type ApprovalStage struct {
+	Name      string `yaml:"name" mapstructure:"name" json:"name,omitempty" gorm:"column:name" bson:"name,omitempty" dynamodbav:"name,omitempty" firestore:"name,omitempty"`
+	Approvals int    `yaml:"approvals" mapstructure:"approvals" json:"approvals,omitempty" gorm:"column:approvals" bson:"approvals,omitempty" dynamodbav:"approvals,omitempty" firestore:"approvals,omitempty"`
+}
+
+type ApprovalProgress struct {
+	Required int      `yaml:"required" mapstructure:"required" json:"required" gorm:"column:required" bson:"required" dynamodbav:"required" firestore:"required"`
+	Approved int      `yaml:"approved" mapstructure:"approved" json:"approved" gorm:"column:approved" bson:"approved" dynamodbav:"approved" firestore:"approved"`
+	Stage    string   `yaml:"stage" mapstructure:"stage" json:"stage,omitempty" gorm:"column:stage" bson:"stage,omitempty" dynamodbav:"stage,omitempty" firestore:"stage,omitempty"`
+	By       []string `yaml:"by" mapstructure:"by" json:"by,omitempty" gorm:"column:by" bson:"by,omitempty" dynamodbav:"by,omitempty" firestore:"by,omitempty"`
+}
+
+type ApprovalConfig struct {
+	Id         string `yaml:"id" mapstructure:"id" json:"id,omitempty" gorm:"column:id" bson:"_id,omitempty" dynamodbav:"id,omitempty" firestore:"id,omitempty"`
+	EntityType string `yaml:"entity_type" mapstructure:"entity_type" json:"entityType,omitempty" gorm:"column:entitytype" bson:"entityType,omitempty" dynamodbav:"entityType,omitempty" firestore:"entityType,omitempty"`
+	Stage      string `yaml:"stage" mapstructure:"stage" json:"stage,omitempty" gorm:"column:stage" bson:"stage,omitempty" dynamodbav:"stage,omitempty" firestore:"stage,omitempty"`
+	ApprovedBy string `yaml:"approved_by" mapstructure:"approved_by" json:"approvedBy,omitempty" gorm:"column:approvedBy" bson:"approvedBy,omitempty" dynamodbav:"approvedBy,omitempty" firestore:"approvedBy,omitempty"`
+	Timestamp  string `yaml:"timestamp" mapstructure:"timestamp" json:"timestamp,omitempty" gorm:"column:timestamp" bson:"timestamp,omitempty" dynamodbav:"timestamp,omitempty" firestore:"timestamp,omitempty"`
+}
+
+// Quorum declares a single stage which needs n independent approvals.
+func Quorum(n int) []ApprovalStage {
+	return []ApprovalStage{{Approvals: n}}
+}
+
+func BuildProgress(stages []ApprovalStage, by []string) ApprovalProgress {
+	progress := ApprovalProgress{Approved: len(by), By: by}
+	passed := 0
+	for _, stage := range stages {
+		progress.Required += stage.Approvals
+		if len(progress.Stage) == 0 && passed+stage.Approvals > len(by) {
+			progress.Stage = stage.Name
+		}
+		passed += stage.Approvals
+	}
+	return progress
+}
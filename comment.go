@@ -0,0 +1,93 @@
+package diff
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	StatePending          = "pending"
+	StateChangesRequested = "changes_requested"
+	StateApproved         = "approved"
+	StateRejected         = "rejected"
+
+	CommentActionComment        = "comment"
+	CommentActionApprove        = "approve"
+	CommentActionReject         = "reject"
+	CommentActionRequestChanges = "request_changes"
+)
+
+type Comment struct {
+	Author    string     `yaml:"author" mapstructure:"author" json:"author,omitempty" gorm:"column:author" bson:"author,omitempty" dynamodbav:"author,omitempty" firestore:"author,omitempty"`
+	Text      string     `yaml:"text" mapstructure:"text" json:"text,omitempty" gorm:"column:text" bson:"text,omitempty" dynamodbav:"text,omitempty" firestore:"text,omitempty"`
+	Action    string     `yaml:"action" mapstructure:"action" json:"action,omitempty" gorm:"column:action" bson:"action,omitempty" dynamodbav:"action,omitempty" firestore:"action,omitempty"`
+	Timestamp *time.Time `yaml:"timestamp" mapstructure:"timestamp" json:"timestamp,omitempty" gorm:"column:timestamp" bson:"timestamp,omitempty" dynamodbav:"timestamp,omitempty" firestore:"timestamp,omitempty"`
+}
+
+// Review is the optional body of an approve or reject request.
+type Review struct {
+	Reason  string `yaml:"reason" mapstructure:"reason" json:"reason,omitempty" gorm:"column:reason" bson:"reason,omitempty" dynamodbav:"reason,omitempty" firestore:"reason,omitempty"`
+	Comment string `yaml:"comment" mapstructure:"comment" json:"comment,omitempty" gorm:"column:comment" bson:"comment,omitempty" dynamodbav:"comment,omitempty" firestore:"comment,omitempty"`
+}
+
+type CommentService interface {
+	Comment(ctx context.Context, id interface{}, text string) (int, error)
+}
+
+type reviewKey struct{}
+
+// WithReview passes the review of a request to ApprService, whose signature only takes the id.
+func WithReview(ctx context.Context, review *Review) context.Context {
+	if review == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, reviewKey{}, review)
+}
+
+func GetReview(ctx context.Context) *Review {
+	if review, ok := ctx.Value(reviewKey{}).(*Review); ok {
+		return review
+	}
+	return nil
+}
+
+// DecodeReview returns nil if the body of r is empty.
+func DecodeReview(r *http.Request) (*Review, error) {
+	if r.Body == nil {
+		return nil, nil
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil, nil
+	}
+	var review Review
+	err = json.Unmarshal(body, &review)
+	if err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+// buildReviewComment returns the comment of the reviewer for the thread, or nil if the review has no text.
+func buildReviewComment(review *Review, author string, action string) *Comment {
+	if review == nil {
+		return nil
+	}
+	text := review.Comment
+	if len(text) == 0 {
+		text = review.Reason
+	}
+	if len(text) == 0 {
+		return nil
+	}
+	now := time.Now()
+	return &Comment{Author: author, Text: text, Action: action, Timestamp: &now}
+}
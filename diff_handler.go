@@ -13,6 +13,7 @@ type DiffModelConfig struct {
 	By       string `yaml:"by" mapstructure:"by" json:"by,omitempty" gorm:"column:by" bson:"by,omitempty" dynamodbav:"by,omitempty" firestore:"by,omitempty"`
 	Resource string `yaml:"resource" mapstructure:"resource" json:"resource,omitempty" gorm:"column:resource" bson:"resource,omitempty" dynamodbav:"resource,omitempty" firestore:"resource,omitempty"`
 	Action   string `yaml:"action" mapstructure:"action" json:"action,omitempty" gorm:"column:action" bson:"action,omitempty" dynamodbav:"action,omitempty" firestore:"action,omitempty"`
+	Changes  string `yaml:"changes" mapstructure:"changes" json:"changes,omitempty" gorm:"column:changes" bson:"changes,omitempty" dynamodbav:"changes,omitempty" firestore:"changes,omitempty"`
 }
 type DiffHandler struct {
 	GetDiff   func(ctx context.Context, id interface{}) (*DiffModel, error)
@@ -25,6 +26,7 @@ type DiffHandler struct {
 	Resource  string
 	Action    string
 	Config    *DiffModelConfig
+	Renderer  *Renderer
 }
 
 func NewDiffHandler(diff func(context.Context, interface{}) (*DiffModel, error), modelType reflect.Type, logError func(context.Context, string), config *DiffModelConfig, writeLog func(context.Context, string, string, bool, string) error, options ...int) *DiffHandler {
@@ -50,7 +52,7 @@ func NewDiffHandlerWithKeys(diff func(context.Context, interface{}) (*DiffModel,
 	if len(action) == 0 {
 		action = "diff"
 	}
-	return &DiffHandler{Log: writeLog, GetDiff: diff, ModelType: modelType, Keys: keys, Indexes: indexes, Resource: resource, Offset: offset, Config: config, Error: logError}
+	return &DiffHandler{Log: writeLog, GetDiff: diff, ModelType: modelType, Keys: keys, Indexes: indexes, Resource: resource, Offset: offset, Config: config, Error: logError, Renderer: NewRenderer(modelType)}
 }
 
 func (c *DiffHandler) Diff(w http.ResponseWriter, r *http.Request) {
@@ -61,8 +63,15 @@ func (c *DiffHandler) Diff(w http.ResponseWriter, r *http.Request) {
 		result, er2 := c.GetDiff(r.Context(), id)
 		if er2 != nil {
 			handleError(w, r, http.StatusInternalServerError, internalServerError, c.Error, c.Resource, c.Action, er2, c.Log)
+		} else if result == nil {
+			succeed(w, r, http.StatusNotFound, result, c.Log, c.Resource, c.Action)
 		} else {
-			if c.Config == nil {
+			if format := GetRenderFormat(r); len(format) > 0 && c.Renderer != nil {
+				respondText(w, r, http.StatusOK, GetRenderContentType(format), c.Renderer.Render(*result, format), c.Log, c.Resource, c.Action)
+			} else if IsJsonPatch(r) {
+				w.Header().Set("Content-Type", ContentTypeJsonPatch)
+				succeed(w, r, http.StatusOK, BuildJsonPatch(result.Origin, result.Value), c.Log, c.Resource, c.Action)
+			} else if c.Config == nil {
 				succeed(w, r, http.StatusOK, result, c.Log, c.Resource, c.Action)
 			} else {
 				m := make(map[string]interface{})
@@ -78,6 +87,9 @@ func (c *DiffHandler) Diff(w http.ResponseWriter, r *http.Request) {
 				if len(result.By) > 0 {
 					m[c.Config.By] = result.By
 				}
+				if len(c.Config.Changes) > 0 {
+					m[c.Config.Changes] = result.Changes
+				}
 				succeed(w, r, http.StatusOK, m, c.Log, c.Resource, c.Action)
 			}
 		}
@@ -67,6 +67,9 @@ func (c *DiffListHandler) DiffList(w http.ResponseWriter, r *http.Request) {
 					if len(result.By) > 0 {
 						m[c.Config.By] = result.By
 					}
+					if len(c.Config.Changes) > 0 {
+						m[c.Config.Changes] = result.Changes
+					}
 					l = append(l, m)
 				}
 				succeed(w, r, http.StatusOK, l, c.Log, c.Resource, c.Action)
@@ -1,8 +1,53 @@
 package diff
 
+import "time"
+
 type DiffModel struct {
-	Id     interface{} `yaml:"id" mapstructure:"id" json:"id,omitempty" gorm:"column:id" bson:"_id,omitempty" dynamodbav:"id,omitempty" firestore:"id,omitempty"`
-	Origin interface{} `yaml:"origin" mapstructure:"origin" json:"origin,omitempty" gorm:"column:origin" bson:"origin,omitempty" dynamodbav:"origin,omitempty" firestore:"origin,omitempty"`
-	Value  interface{} `yaml:"value" mapstructure:"value" json:"value,omitempty" gorm:"column:value" bson:"value,omitempty" dynamodbav:"value,omitempty" firestore:"value,omitempty"`
-	By     string      `yaml:"by" mapstructure:"by" json:"by,omitempty" gorm:"column:updated_by" bson:"by,omitempty" dynamodbav:"by,omitempty" firestore:"by,omitempty"`
+	Id         interface{}       `yaml:"id" mapstructure:"id" json:"id,omitempty" gorm:"column:id" bson:"_id,omitempty" dynamodbav:"id,omitempty" firestore:"id,omitempty"`
+	Origin     interface{}       `yaml:"origin" mapstructure:"origin" json:"origin,omitempty" gorm:"column:origin" bson:"origin,omitempty" dynamodbav:"origin,omitempty" firestore:"origin,omitempty"`
+	Value      interface{}       `yaml:"value" mapstructure:"value" json:"value,omitempty" gorm:"column:value" bson:"value,omitempty" dynamodbav:"value,omitempty" firestore:"value,omitempty"`
+	By         string            `yaml:"by" mapstructure:"by" json:"by,omitempty" gorm:"column:updated_by" bson:"by,omitempty" dynamodbav:"by,omitempty" firestore:"by,omitempty"`
+	Changes    []FieldChange     `yaml:"changes" mapstructure:"changes" json:"changes,omitempty" gorm:"-" bson:"changes,omitempty" dynamodbav:"changes,omitempty" firestore:"changes,omitempty"`
+	Progress   *ApprovalProgress `yaml:"progress" mapstructure:"progress" json:"progress,omitempty" gorm:"-" bson:"progress,omitempty" dynamodbav:"progress,omitempty" firestore:"progress,omitempty"`
+	ApprovedBy string            `yaml:"approved_by" mapstructure:"approved_by" json:"approvedBy,omitempty" gorm:"column:approved_by" bson:"approvedBy,omitempty" dynamodbav:"approvedBy,omitempty" firestore:"approvedBy,omitempty"`
+	Timestamp  *time.Time        `yaml:"timestamp" mapstructure:"timestamp" json:"timestamp,omitempty" gorm:"column:timestamp" bson:"timestamp,omitempty" dynamodbav:"timestamp,omitempty" firestore:"timestamp,omitempty"`
+	EntityType string            `yaml:"entity_type" mapstructure:"entity_type" json:"entityType,omitempty" gorm:"column:entity_type" bson:"entityType,omitempty" dynamodbav:"entityType,omitempty" firestore:"entityType,omitempty"`
+	Kind       string            `yaml:"kind" mapstructure:"kind" json:"kind,omitempty" gorm:"column:kind" bson:"kind,omitempty" dynamodbav:"kind,omitempty" firestore:"kind,omitempty"`
+	State      string            `yaml:"state" mapstructure:"state" json:"state,omitempty" gorm:"column:state" bson:"state,omitempty" dynamodbav:"state,omitempty" firestore:"state,omitempty"`
+	Reason     string            `yaml:"reason" mapstructure:"reason" json:"reason,omitempty" gorm:"column:reason" bson:"reason,omitempty" dynamodbav:"reason,omitempty" firestore:"reason,omitempty"`
+	Comments   []Comment         `yaml:"comments" mapstructure:"comments" json:"comments,omitempty" gorm:"-" bson:"comments,omitempty" dynamodbav:"comments,omitempty" firestore:"comments,omitempty"`
+	Revisions  []Revision        `yaml:"revisions" mapstructure:"revisions" json:"revisions,omitempty" gorm:"-" bson:"revisions,omitempty" dynamodbav:"revisions,omitempty" firestore:"revisions,omitempty"`
+}
+
+const (
+	KindCreate = "create"
+	KindUpdate = "update"
+	KindDelete = "delete"
+)
+
+// GetKind returns kind if it is set, otherwise infers it: a change without origin creates the record, a change without value deletes it.
+func GetKind(kind string, origin interface{}, value interface{}) string {
+	if len(kind) > 0 {
+		return kind
+	}
+	if isNilJson(value) {
+		return KindDelete
+	}
+	if isNilJson(origin) {
+		return KindCreate
+	}
+	return KindUpdate
+}
+
+func isNilJson(v interface{}) bool {
+	switch m := toJsonValue(v).(type) {
+	case nil:
+		return true
+	case map[string]interface{}:
+		return m == nil
+	case string:
+		return len(m) == 0 || m == "null"
+	default:
+		return false
+	}
 }
@@ -0,0 +1,256 @@
+package diff
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+type DiffModelOf[T any] struct {
+	Id         interface{}       `yaml:"id" mapstructure:"id" json:"id,omitempty" gorm:"column:id;primary_key" bson:"_id,omitempty" dynamodbav:"id,omitempty" firestore:"id,omitempty"`
+	Origin     *T                `yaml:"origin" mapstructure:"origin" json:"origin,omitempty" gorm:"column:origin" bson:"origin,omitempty" dynamodbav:"origin,omitempty" firestore:"origin,omitempty"`
+	Value      *T                `yaml:"value" mapstructure:"value" json:"value,omitempty" gorm:"column:value" bson:"value,omitempty" dynamodbav:"value,omitempty" firestore:"value,omitempty"`
+	By         string            `yaml:"by" mapstructure:"by" json:"by,omitempty" gorm:"column:updated_by" bson:"by,omitempty" dynamodbav:"by,omitempty" firestore:"by,omitempty"`
+	Changes    []FieldChange     `yaml:"changes" mapstructure:"changes" json:"changes,omitempty" gorm:"-" bson:"changes,omitempty" dynamodbav:"changes,omitempty" firestore:"changes,omitempty"`
+	Progress   *ApprovalProgress `yaml:"progress" mapstructure:"progress" json:"progress,omitempty" gorm:"-" bson:"progress,omitempty" dynamodbav:"progress,omitempty" firestore:"progress,omitempty"`
+	ApprovedBy string            `yaml:"approved_by" mapstructure:"approved_by" json:"approvedBy,omitempty" gorm:"column:approved_by" bson:"approvedBy,omitempty" dynamodbav:"approvedBy,omitempty" firestore:"approvedBy,omitempty"`
+	Timestamp  *time.Time        `yaml:"timestamp" mapstructure:"timestamp" json:"timestamp,omitempty" gorm:"column:timestamp" bson:"timestamp,omitempty" dynamodbav:"timestamp,omitempty" firestore:"timestamp,omitempty"`
+	EntityType string            `yaml:"entity_type" mapstructure:"entity_type" json:"entityType,omitempty" gorm:"column:entity_type" bson:"entityType,omitempty" dynamodbav:"entityType,omitempty" firestore:"entityType,omitempty"`
+	Kind       string            `yaml:"kind" mapstructure:"kind" json:"kind,omitempty" gorm:"column:kind" bson:"kind,omitempty" dynamodbav:"kind,omitempty" firestore:"kind,omitempty"`
+}
+
+type DiffServiceOf[K any, T any] interface {
+	Diff(ctx context.Context, id K) (*DiffModelOf[T], error)
+}
+
+type DiffListServiceOf[K any, T any] interface {
+	Diff(ctx context.Context, ids []K) ([]DiffModelOf[T], error)
+}
+
+type ApprServiceOf[K any] interface {
+	Approve(ctx context.Context, id K) (int, error)
+	Reject(ctx context.Context, id K) (int, error)
+}
+
+// DiffReaderOf decodes the changes of an untyped DiffService into T.
+type DiffReaderOf[K any, T any] struct {
+	DiffService DiffService
+}
+
+type DiffListReaderOf[K any, T any] struct {
+	DiffListService DiffListService
+}
+
+type ApproverOf[K any] struct {
+	ApprService ApprService
+}
+
+// DiffServiceAdapter and ApprServiceAdapter expose typed services through the untyped interfaces, so that they can be used by the handlers.
+type DiffServiceAdapter[K any, T any] struct {
+	DiffService DiffServiceOf[K, T]
+}
+
+type ApprServiceAdapter[K any] struct {
+	ApprService ApprServiceOf[K]
+}
+
+func NewDiffReaderOf[K any, T any](diffService DiffService) *DiffReaderOf[K, T] {
+	return &DiffReaderOf[K, T]{DiffService: diffService}
+}
+
+func NewDiffListReaderOf[K any, T any](diffListService DiffListService) *DiffListReaderOf[K, T] {
+	return &DiffListReaderOf[K, T]{DiffListService: diffListService}
+}
+
+func NewApproverOf[K any](apprService ApprService) *ApproverOf[K] {
+	return &ApproverOf[K]{ApprService: apprService}
+}
+
+func NewDiffServiceAdapter[K any, T any](diffService DiffServiceOf[K, T]) *DiffServiceAdapter[K, T] {
+	return &DiffServiceAdapter[K, T]{DiffService: diffService}
+}
+
+func NewApprServiceAdapter[K any](apprService ApprServiceOf[K]) *ApprServiceAdapter[K] {
+	return &ApprServiceAdapter[K]{ApprService: apprService}
+}
+
+func (s ApproverOf[K]) GetStatus() StatusConfig {
+	return GetServiceStatus(s.ApprService)
+}
+
+func (s ApprServiceAdapter[K]) GetStatus() StatusConfig {
+	return GetServiceStatus(s.ApprService)
+}
+
+func NewSqlDiffReaderOf[K any, T any](db *sql.DB, table string, entity string, entityType string, idNames []string, config DiffConfig, keyBuilder KeyBuilder, options ...func(int) string) *DiffReaderOf[K, T] {
+	return NewDiffReaderOf[K, T](NewSqlDiffReader(db, table, entity, entityType, idNames, config, keyBuilder, options...))
+}
+
+func NewSqlDiffListReaderOf[K any, T any](db *sql.DB, table string, entity string, entityType string, idNames []string, config DiffConfig, keyBuilder KeyBuilder, options ...func(int) string) *DiffListReaderOf[K, T] {
+	return NewDiffListReaderOf[K, T](NewSqlDiffListReader(db, table, entity, entityType, idNames, config, keyBuilder, options...))
+}
+
+func (r DiffReaderOf[K, T]) Diff(ctx context.Context, id K) (*DiffModelOf[T], error) {
+	result, err := r.DiffService.Diff(ctx, toUntypedKey(id))
+	if err != nil || result == nil {
+		return nil, err
+	}
+	return ToDiffModelOf[T](*result)
+}
+
+func (r DiffListReaderOf[K, T]) Diff(ctx context.Context, ids []K) ([]DiffModelOf[T], error) {
+	keys := make([]interface{}, 0)
+	for _, id := range ids {
+		keys = append(keys, toUntypedKey(id))
+	}
+	results, err := r.DiffListService.Diff(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]DiffModelOf[T], 0)
+	if results == nil {
+		return list, nil
+	}
+	for _, result := range *results {
+		m, err := ToDiffModelOf[T](result)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *m)
+	}
+	return list, nil
+}
+
+func (r ApproverOf[K]) Approve(ctx context.Context, id K) (int, error) {
+	return r.ApprService.Approve(ctx, toUntypedKey(id))
+}
+
+func (r ApproverOf[K]) Reject(ctx context.Context, id K) (int, error) {
+	return r.ApprService.Reject(ctx, toUntypedKey(id))
+}
+
+func (a DiffServiceAdapter[K, T]) Diff(ctx context.Context, id interface{}) (*DiffModel, error) {
+	key, err := ToKey[K](id)
+	if err != nil {
+		return nil, err
+	}
+	result, err := a.DiffService.Diff(ctx, key)
+	if err != nil || result == nil {
+		return nil, err
+	}
+	return ToDiffModel(*result)
+}
+
+func (a ApprServiceAdapter[K]) Approve(ctx context.Context, id interface{}) (int, error) {
+	key, err := ToKey[K](id)
+	if err != nil {
+		return 0, err
+	}
+	return a.ApprService.Approve(ctx, key)
+}
+
+func (a ApprServiceAdapter[K]) Reject(ctx context.Context, id interface{}) (int, error) {
+	key, err := ToKey[K](id)
+	if err != nil {
+		return 0, err
+	}
+	return a.ApprService.Reject(ctx, key)
+}
+
+// ToDiffModelOf decodes origin and value of m into T.
+func ToDiffModelOf[T any](m DiffModel) (*DiffModelOf[T], error) {
+	result := &DiffModelOf[T]{Id: m.Id, By: m.By, Changes: m.Changes, Progress: m.Progress, ApprovedBy: m.ApprovedBy, Timestamp: m.Timestamp, EntityType: m.EntityType, Kind: m.Kind}
+	var err error
+	result.Origin, err = decodeAs[T](m.Origin)
+	if err != nil {
+		return nil, err
+	}
+	result.Value, err = decodeAs[T](m.Value)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func ToDiffModel[T any](m DiffModelOf[T]) (*DiffModel, error) {
+	result := &DiffModel{Id: m.Id, By: m.By, Changes: m.Changes, Progress: m.Progress, ApprovedBy: m.ApprovedBy, Timestamp: m.Timestamp, EntityType: m.EntityType, Kind: m.Kind}
+	if m.Origin != nil {
+		origin, err := toMap(m.Origin)
+		if err != nil {
+			return nil, err
+		}
+		result.Origin = origin
+	}
+	if m.Value != nil {
+		value, err := toMap(m.Value)
+		if err != nil {
+			return nil, err
+		}
+		result.Value = value
+	}
+	if result.Changes == nil {
+		result.Changes = BuildChanges(result.Origin, result.Value)
+	}
+	return result, nil
+}
+
+// ToKey converts an id built by the handlers, such as a string, an int64 or a map of a composite key, to K.
+func ToKey[K any](id interface{}) (K, error) {
+	var key K
+	if k, ok := id.(K); ok {
+		return k, nil
+	}
+	b, err := json.Marshal(id)
+	if err != nil {
+		return key, err
+	}
+	err = json.Unmarshal(b, &key)
+	if err != nil {
+		return key, fmt.Errorf("invalid id %v: %w", id, err)
+	}
+	return key, nil
+}
+
+// toUntypedKey passes scalar keys as they are and converts struct keys to maps, as expected by the untyped services.
+func toUntypedKey(id interface{}) interface{} {
+	switch id.(type) {
+	case string, int, int32, int64, map[string]interface{}:
+		return id
+	}
+	if m, err := toMap(id); err == nil {
+		return m
+	}
+	return id
+}
+
+func decodeAs[T any](v interface{}) (*T, error) {
+	if isNilJson(v) {
+		return nil, nil
+	}
+	var b []byte
+	if s, ok := v.(string); ok {
+		b = []byte(s)
+	} else {
+		var err error
+		b, err = json.Marshal(toJsonValue(v))
+		if err != nil {
+			return nil, err
+		}
+	}
+	var t T
+	err := json.Unmarshal(b, &t)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func toMap(v interface{}) (map[string]interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return decodeJsonObject(string(b))
+}
@@ -0,0 +1,149 @@
+package diff_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	d "github.com/core-go/diff"
+)
+
+type diffFunc func(ctx context.Context, id interface{}) (*d.DiffModel, error)
+
+func (f diffFunc) Diff(ctx context.Context, id interface{}) (*d.DiffModel, error) {
+	return f(ctx, id)
+}
+
+type ItemKey struct {
+	Id   string `json:"id"`
+	Code string `json:"code"`
+}
+
+func TestToKey(t *testing.T) {
+	tests := []struct {
+		name string
+		id   interface{}
+		want interface{}
+		err  bool
+	}{
+		{"a string", "a", "a", false},
+		{"an int64 to an int", int64(7), 7, false},
+		{"a float to an int64", 7.0, int64(7), false},
+		{"a map to a struct", map[string]interface{}{"id": "a", "code": "x"}, ItemKey{Id: "a", Code: "x"}, false},
+		{"a struct as it is", ItemKey{Id: "a"}, ItemKey{Id: "a"}, false},
+		{"a string to an int", "a", 0, true},
+	}
+	for _, test := range tests {
+		var key interface{}
+		var err error
+		switch test.want.(type) {
+		case string:
+			key, err = d.ToKey[string](test.id)
+		case int:
+			key, err = d.ToKey[int](test.id)
+		case int64:
+			key, err = d.ToKey[int64](test.id)
+		case ItemKey:
+			key, err = d.ToKey[ItemKey](test.id)
+		}
+		if test.err {
+			if err == nil {
+				t.Errorf("%s: key = %v, want an error", test.name, key)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(key, test.want) {
+			t.Errorf("%s: key = %#v %v, want %#v", test.name, key, err, test.want)
+		}
+	}
+}
+
+func TestToDiffModelOf(t *testing.T) {
+	tests := []struct {
+		name   string
+		origin interface{}
+		value  interface{}
+		want   [2]*Item
+	}{
+		{"an update of maps", map[string]interface{}{"id": "a", "name": "A"}, map[string]interface{}{"id": "a", "name": "B"}, [2]*Item{{Id: "a", Name: "A"}, {Id: "a", Name: "B"}}},
+		{"pointers to maps", &map[string]interface{}{"name": "A"}, &map[string]interface{}{"name": "B"}, [2]*Item{{Name: "A"}, {Name: "B"}}},
+		{"json strings", `{"name":"A"}`, `{"name":"B"}`, [2]*Item{{Name: "A"}, {Name: "B"}}},
+		{"a create", nil, map[string]interface{}{"name": "B"}, [2]*Item{nil, {Name: "B"}}},
+		{"a delete", map[string]interface{}{"name": "A"}, "null", [2]*Item{{Name: "A"}, nil}},
+		{"a nil pointer", (*map[string]interface{})(nil), "", [2]*Item{nil, nil}},
+	}
+	for _, test := range tests {
+		m, err := d.ToDiffModelOf[Item](d.DiffModel{Id: "a", By: "maker", Origin: test.origin, Value: test.value, Kind: d.KindUpdate})
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if !reflect.DeepEqual(m.Origin, test.want[0]) || !reflect.DeepEqual(m.Value, test.want[1]) {
+			t.Errorf("%s: origin = %+v, value = %+v, want %+v, %+v", test.name, m.Origin, m.Value, test.want[0], test.want[1])
+		}
+		if m.Id != "a" || m.By != "maker" || m.Kind != d.KindUpdate {
+			t.Errorf("%s: diff = %+v, want the fields of the untyped diff", test.name, m)
+		}
+	}
+	if _, err := d.ToDiffModelOf[Item](d.DiffModel{Value: `{"name":1}`}); err == nil {
+		t.Error("a value which is not an Item must be an error")
+	}
+}
+
+func TestToDiffModel(t *testing.T) {
+	tests := []struct {
+		name    string
+		model   d.DiffModelOf[Item]
+		changes []d.FieldChange
+	}{
+		{"changes are built", d.DiffModelOf[Item]{Origin: &Item{Id: "a", Name: "A"}, Value: &Item{Id: "a", Name: "B"}}, []d.FieldChange{{Path: "name", Old: "A", New: "B", Kind: d.ChangeModified}}},
+		{"a create", d.DiffModelOf[Item]{Value: &Item{Name: "B"}}, []d.FieldChange{{Path: "code", New: "", Kind: d.ChangeAdded}, {Path: "id", New: "", Kind: d.ChangeAdded}, {Path: "name", New: "B", Kind: d.ChangeAdded}}},
+		{"changes are kept", d.DiffModelOf[Item]{Value: &Item{Name: "B"}, Changes: []d.FieldChange{}}, []d.FieldChange{}},
+	}
+	for _, test := range tests {
+		m, err := d.ToDiffModel(test.model)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if !reflect.DeepEqual(m.Changes, test.changes) {
+			t.Errorf("%s: changes = %+v, want %+v", test.name, m.Changes, test.changes)
+		}
+		if (m.Origin == nil) != (test.model.Origin == nil) || (m.Value == nil) != (test.model.Value == nil) {
+			t.Errorf("%s: origin = %v, value = %v, want maps only for the set fields", test.name, m.Origin, m.Value)
+		}
+	}
+}
+
+func TestDiffReaderOfPassesUntypedKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		id   interface{}
+		want interface{}
+	}{
+		{"a string", "a", "a"},
+		{"an int64", int64(7), int64(7)},
+		{"a struct", ItemKey{Id: "a", Code: "x"}, map[string]interface{}{"id": "a", "code": "x"}},
+	}
+	for _, test := range tests {
+		var got interface{}
+		service := diffFunc(func(ctx context.Context, id interface{}) (*d.DiffModel, error) {
+			got = id
+			return nil, nil
+		})
+		var m interface{}
+		var err error
+		switch id := test.id.(type) {
+		case string:
+			m, err = d.NewDiffReaderOf[string, Item](service).Diff(context.Background(), id)
+		case int64:
+			m, err = d.NewDiffReaderOf[int64, Item](service).Diff(context.Background(), id)
+		case ItemKey:
+			m, err = d.NewDiffReaderOf[ItemKey, Item](service).Diff(context.Background(), id)
+		}
+		if err != nil || !reflect.ValueOf(m).IsNil() {
+			t.Errorf("%s: diff = %v %v, want nil for a missing change", test.name, m, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: key = %#v, want %#v", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package diff
+
+import (
+	"context"
+	"net/http"
+)
+
+type DiffSearchHandler struct {
+	DiffSearchService DiffSearchService
+	Error             func(context.Context, string)
+	Log               func(ctx context.Context, resource string, action string, success bool, desc string) error
+	Resource          string
+	Action            string
+}
+
+func NewDiffSearchHandler(diffSearchService DiffSearchService, logError func(context.Context, string), writeLog func(context.Context, string, string, bool, string) error, options ...string) *DiffSearchHandler {
+	var resource, action string
+	if len(options) > 0 && len(options[0]) > 0 {
+		resource = options[0]
+	} else {
+		resource = "diff"
+	}
+	if len(options) > 1 && len(options[1]) > 0 {
+		action = options[1]
+	} else {
+		action = "search"
+	}
+	return &DiffSearchHandler{DiffSearchService: diffSearchService, Error: logError, Log: writeLog, Resource: resource, Action: action}
+}
+
+func (c *DiffSearchHandler) Search(w http.ResponseWriter, r *http.Request) {
+	filter, er1 := BuildDiffFilter(r)
+	if er1 != nil {
+		http.Error(w, er1.Error(), http.StatusBadRequest)
+		return
+	}
+	list, total, er2 := c.DiffSearchService.Search(r.Context(), filter)
+	if er2 != nil {
+		handleError(w, r, http.StatusInternalServerError, internalServerError, c.Error, c.Resource, c.Action, er2, c.Log)
+	} else {
+		succeed(w, r, http.StatusOK, SearchResult{List: list, Total: total}, c.Log, c.Resource, c.Action)
+	}
+}
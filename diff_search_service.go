@@ -0,0 +1,47 @@
+package diff
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type DiffFilter struct {
+	EntityType []string   `yaml:"entity_type" mapstructure:"entity_type" json:"entityType,omitempty" gorm:"column:entitytype" bson:"entityType,omitempty" dynamodbav:"entityType,omitempty" firestore:"entityType,omitempty"`
+	Id         string     `yaml:"id" mapstructure:"id" json:"id,omitempty" gorm:"column:id" bson:"_id,omitempty" dynamodbav:"id,omitempty" firestore:"id,omitempty"`
+	By         string     `yaml:"by" mapstructure:"by" json:"by,omitempty" gorm:"column:by" bson:"by,omitempty" dynamodbav:"by,omitempty" firestore:"by,omitempty"`
+	State      string     `yaml:"state" mapstructure:"state" json:"state,omitempty" gorm:"column:state" bson:"state,omitempty" dynamodbav:"state,omitempty" firestore:"state,omitempty"`
+	From       *time.Time `yaml:"from" mapstructure:"from" json:"from,omitempty" gorm:"column:from" bson:"from,omitempty" dynamodbav:"from,omitempty" firestore:"from,omitempty"`
+	To         *time.Time `yaml:"to" mapstructure:"to" json:"to,omitempty" gorm:"column:to" bson:"to,omitempty" dynamodbav:"to,omitempty" firestore:"to,omitempty"`
+	Sort       string     `yaml:"sort" mapstructure:"sort" json:"sort,omitempty" gorm:"column:sort" bson:"sort,omitempty" dynamodbav:"sort,omitempty" firestore:"sort,omitempty"`
+	Page       int64      `yaml:"page" mapstructure:"page" json:"page,omitempty" gorm:"column:page" bson:"page,omitempty" dynamodbav:"page,omitempty" firestore:"page,omitempty"`
+	Limit      int64      `yaml:"limit" mapstructure:"limit" json:"limit,omitempty" gorm:"column:limit" bson:"limit,omitempty" dynamodbav:"limit,omitempty" firestore:"limit,omitempty"`
+}
+
+type DiffSearchService interface {
+	Search(ctx context.Context, filter DiffFilter) ([]DiffModel, int64, error)
+}
+
+// BuildDiffFilter reads entityType (comma separated), id, by, state, from, to (RFC 3339), sort, page and limit from the query string.
+func BuildDiffFilter(r *http.Request) (DiffFilter, error) {
+	q := r.URL.Query()
+	filter := DiffFilter{Id: q.Get("id"), By: q.Get("by"), State: q.Get("state"), Sort: q.Get("sort")}
+	if s := q.Get("entityType"); len(s) > 0 {
+		filter.EntityType = strings.Split(s, ",")
+	}
+	var err error
+	if filter.From, err = parseTime(q.Get("from")); err != nil {
+		return filter, err
+	}
+	if filter.To, err = parseTime(q.Get("to")); err != nil {
+		return filter, err
+	}
+	if filter.Page, err = parseInt(q.Get("page")); err != nil {
+		return filter, err
+	}
+	if filter.Limit, err = parseInt(q.Get("limit")); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
@@ -0,0 +1,276 @@
+package difftest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	d "github.com/core-go/diff"
+)
+
+// Backend is an implementation under test. Seed and Get access the live records directly; SetUser sets the user returned by the GetUser function of the implementation.
+// Stage stages the change of the record of id to value by the current user, for an implementation without SubmitService; Submit is used when Stage is nil.
+type Backend struct {
+	Diff     d.DiffService
+	DiffList d.DiffListService
+	Appr     d.ApprService
+	ApprList d.ApprListService
+	Submit   d.SubmitService
+	Stage    func(ctx context.Context, id interface{}, value map[string]interface{}) error
+	Status   d.StatusConfig
+	SetUser  func(user string)
+	Seed     func(ctx context.Context, id interface{}, record map[string]interface{}) error
+	Get      func(ctx context.Context, id interface{}) (map[string]interface{}, error)
+	History  func(ctx context.Context) ([]d.DiffModel, error)
+}
+
+// Factory returns a new, empty backend for records with the json fields of idNames, "name" and "version", where "version" is the version field.
+type Factory func(t *testing.T, idNames []string) *Backend
+
+// RunServiceSuite runs the conformance tests with single and composite keys; DiffList, ApprList and History are optional.
+func RunServiceSuite(t *testing.T, factory Factory) {
+	keys := []struct {
+		name    string
+		idNames []string
+		id      func(n int) interface{}
+	}{
+		{"single key", []string{"id"}, func(n int) interface{} { return fmt.Sprint("id", n) }},
+		{"composite key", []string{"id", "code"}, func(n int) interface{} {
+			return map[string]interface{}{"id": fmt.Sprint("id", n), "code": "c1"}
+		}},
+	}
+	for _, k := range keys {
+		k := k
+		t.Run(k.name, func(t *testing.T) {
+			s := suite{factory: factory, idNames: k.idNames, id: k.id}
+			t.Run("NotFound", s.testNotFound)
+			t.Run("SubmitAndDiff", s.testSubmitAndDiff)
+			t.Run("Approve", s.testApprove)
+			t.Run("SelfApproval", s.testSelfApproval)
+			t.Run("Reject", s.testReject)
+			t.Run("VersionConflict", s.testVersionConflict)
+			t.Run("History", s.testHistory)
+			t.Run("DiffList", s.testDiffList)
+			t.Run("ApproveList", s.testApproveList)
+		})
+	}
+}
+
+type suite struct {
+	factory Factory
+	idNames []string
+	id      func(n int) interface{}
+}
+
+func (s suite) setup(t *testing.T, n int) (*Backend, context.Context) {
+	b := s.factory(t, s.idNames)
+	if b == nil || b.Diff == nil || b.Appr == nil || (b.Submit == nil && b.Stage == nil) || b.Seed == nil || b.Get == nil || b.SetUser == nil {
+		t.Fatal("factory must return Diff, Appr, Submit or Stage, Seed, Get and SetUser")
+	}
+	ctx := context.Background()
+	for i := 1; i <= n; i++ {
+		if err := b.Seed(ctx, s.id(i), s.record(i, "name", 1)); err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+	return b, ctx
+}
+
+func (s suite) record(n int, name string, version int) map[string]interface{} {
+	record := map[string]interface{}{"name": name, "version": version}
+	if m, ok := s.id(n).(map[string]interface{}); ok {
+		for k, v := range m {
+			record[k] = v
+		}
+	} else {
+		record[s.idNames[0]] = s.id(n)
+	}
+	return record
+}
+
+func (s suite) stage(t *testing.T, b *Backend, ctx context.Context, n int, name string) {
+	b.SetUser("maker")
+	if b.Stage != nil {
+		if err := b.Stage(ctx, s.id(n), s.record(n, name, 1)); err != nil {
+			t.Fatalf("stage: %v", err)
+		}
+	} else {
+		status, err := b.Submit.Submit(ctx, s.id(n), map[string]interface{}{"name": name})
+		if err != nil || status != b.Status.Success {
+			t.Fatalf("submit: status %d, error %v", status, err)
+		}
+	}
+	b.SetUser("checker")
+}
+
+func (s suite) testNotFound(t *testing.T) {
+	b, ctx := s.setup(t, 1)
+	diff, err := b.Diff.Diff(ctx, s.id(1))
+	if err != nil || diff != nil {
+		t.Errorf("diff without staged change: got %v, %v", diff, err)
+	}
+	expectStatus(t, "approve", b.Status.NotFound, b.Appr.Approve, ctx, s.id(1))
+	expectStatus(t, "reject", b.Status.NotFound, b.Appr.Reject, ctx, s.id(1))
+}
+
+func (s suite) testSubmitAndDiff(t *testing.T) {
+	b, ctx := s.setup(t, 1)
+	s.stage(t, b, ctx, 1, "changed")
+	diff, err := b.Diff.Diff(ctx, s.id(1))
+	if err != nil || diff == nil {
+		t.Fatalf("diff: got %v, %v", diff, err)
+	}
+	if v := field(diff.Value, "name"); v != "changed" {
+		t.Errorf("value name: got %v", v)
+	}
+	if v := field(diff.Origin, "name"); v != "name" {
+		t.Errorf("origin name: got %v", v)
+	}
+	if diff.By != "maker" {
+		t.Errorf("by: got %q", diff.By)
+	}
+	if fmt.Sprint(diff.Id) != fmt.Sprint(s.id(1)) {
+		t.Errorf("id: got %v, want %v", diff.Id, s.id(1))
+	}
+}
+
+func (s suite) testApprove(t *testing.T) {
+	b, ctx := s.setup(t, 1)
+	s.stage(t, b, ctx, 1, "changed")
+	expectStatus(t, "approve", b.Status.Success, b.Appr.Approve, ctx, s.id(1))
+	record, err := b.Get(ctx, s.id(1))
+	if err != nil || record == nil || fmt.Sprint(record["name"]) != "changed" {
+		t.Errorf("record after approve: got %v, %v", record, err)
+	}
+	diff, err := b.Diff.Diff(ctx, s.id(1))
+	if err != nil || diff != nil {
+		t.Errorf("diff after approve: got %v, %v", diff, err)
+	}
+	expectStatus(t, "approve again", b.Status.NotFound, b.Appr.Approve, ctx, s.id(1))
+	expectStatus(t, "reject after approve", b.Status.NotFound, b.Appr.Reject, ctx, s.id(1))
+}
+
+func (s suite) testSelfApproval(t *testing.T) {
+	b, ctx := s.setup(t, 1)
+	s.stage(t, b, ctx, 1, "changed")
+	b.SetUser("maker")
+	expectStatus(t, "self approve", b.Status.Forbidden, b.Appr.Approve, ctx, s.id(1))
+	b.SetUser("checker")
+	expectStatus(t, "approve", b.Status.Success, b.Appr.Approve, ctx, s.id(1))
+}
+
+func (s suite) testReject(t *testing.T) {
+	b, ctx := s.setup(t, 1)
+	s.stage(t, b, ctx, 1, "changed")
+	expectStatus(t, "reject", b.Status.Success, b.Appr.Reject, ctx, s.id(1))
+	record, err := b.Get(ctx, s.id(1))
+	if err != nil || record == nil || fmt.Sprint(record["name"]) != "name" {
+		t.Errorf("record after reject: got %v, %v", record, err)
+	}
+	expectStatus(t, "reject again", b.Status.NotFound, b.Appr.Reject, ctx, s.id(1))
+	expectStatus(t, "approve after reject", b.Status.NotFound, b.Appr.Approve, ctx, s.id(1))
+}
+
+func (s suite) testVersionConflict(t *testing.T) {
+	b, ctx := s.setup(t, 1)
+	s.stage(t, b, ctx, 1, "changed")
+	if err := b.Seed(ctx, s.id(1), s.record(1, "concurrent", 2)); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+	status, err := b.Appr.Approve(ctx, s.id(1))
+	if status != b.Status.VersionError {
+		t.Errorf("approve stale change: got status %d, want %d", status, b.Status.VersionError)
+	}
+	var conflict *d.VersionConflict
+	if !errors.As(err, &conflict) {
+		t.Errorf("approve stale change: got error %v, want *VersionConflict", err)
+	}
+	record, err := b.Get(ctx, s.id(1))
+	if err != nil || record == nil || fmt.Sprint(record["name"]) != "concurrent" {
+		t.Errorf("record after conflict: got %v, %v", record, err)
+	}
+}
+
+func (s suite) testHistory(t *testing.T) {
+	b, ctx := s.setup(t, 1)
+	if b.History == nil {
+		t.Skip("History is not set")
+	}
+	s.stage(t, b, ctx, 1, "changed")
+	expectStatus(t, "approve", b.Status.Success, b.Appr.Approve, ctx, s.id(1))
+	histories, err := b.History(ctx)
+	if err != nil {
+		t.Fatalf("history: %v", err)
+	}
+	if len(histories) != 1 {
+		t.Fatalf("history: got %d entries, want 1", len(histories))
+	}
+	if v := field(histories[0].Value, "name"); v != "changed" {
+		t.Errorf("history value name: got %v", v)
+	}
+	if histories[0].By != "maker" {
+		t.Errorf("history by: got %q", histories[0].By)
+	}
+}
+
+func (s suite) testDiffList(t *testing.T) {
+	b, ctx := s.setup(t, 3)
+	if b.DiffList == nil {
+		t.Skip("DiffList is not set")
+	}
+	s.stage(t, b, ctx, 1, "one")
+	s.stage(t, b, ctx, 2, "two")
+	diffs, err := b.DiffList.Diff(ctx, []interface{}{s.id(1), s.id(2), s.id(3)})
+	if err != nil || diffs == nil {
+		t.Fatalf("diff list: got %v, %v", diffs, err)
+	}
+	if len(*diffs) != 2 {
+		t.Errorf("diff list: got %d changes, want 2", len(*diffs))
+	}
+}
+
+func (s suite) testApproveList(t *testing.T) {
+	b, ctx := s.setup(t, 2)
+	if b.ApprList == nil {
+		t.Skip("ApprList is not set")
+	}
+	s.stage(t, b, ctx, 1, "one")
+	s.stage(t, b, ctx, 2, "two")
+	status, err := b.ApprList.Approve(ctx, []interface{}{s.id(1), s.id(2)})
+	if err != nil || status != b.Status.Success {
+		t.Fatalf("approve list: got status %d, error %v", status, err)
+	}
+	for i := 1; i <= 2; i++ {
+		if diff, err := b.Diff.Diff(ctx, s.id(i)); err != nil || diff != nil {
+			t.Errorf("diff %d after approve list: got %v, %v", i, diff, err)
+		}
+	}
+	status, err = b.ApprList.Approve(ctx, []interface{}{s.id(1)})
+	if err != nil || status != b.Status.NotFound {
+		t.Errorf("approve list again: got status %d, error %v", status, err)
+	}
+}
+
+func expectStatus(t *testing.T, name string, want int, exec func(context.Context, interface{}) (int, error), ctx context.Context, id interface{}) {
+	t.Helper()
+	status, err := exec(ctx, id)
+	if err != nil {
+		t.Errorf("%s: unexpected error %v", name, err)
+	}
+	if status != want {
+		t.Errorf("%s: got status %d, want %d", name, status, want)
+	}
+}
+
+func field(v interface{}, name string) interface{} {
+	switch m := v.(type) {
+	case map[string]interface{}:
+		return m[name]
+	case *map[string]interface{}:
+		if m != nil {
+			return (*m)[name]
+		}
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package echo
 
 import (
 	"context"
+	"errors"
 	d "github.com/core-go/diff"
 	"github.com/labstack/echo/v4"
 	"net/http"
@@ -21,6 +22,11 @@ type ApprHandler struct {
 	Resource    string
 	Action1     string
 	Action2     string
+	Status      d.StatusConfig
+	// CommentService, when set, serves Comment
+	CommentService d.CommentService
+	// ChangeRequestService, when set, serves RequestChanges
+	ChangeRequestService d.ChangeRequestService
 }
 
 func NewApprHandler(apprService d.ApprService, modelType reflect.Type, logError func(context.Context, string), option ...int) *ApprHandler {
@@ -64,7 +70,7 @@ func NewApprHandlerWithKeysAndLog(apprService d.ApprService, keys []string, mode
 	} else {
 		resource = d.BuildResourceName(modelType.Name())
 	}
-	return &ApprHandler{Log: writeLog, ApprService: apprService, ModelType: modelType, Keys: keys, Indexes: indexes, Offset: offset, Error: logError, Resource: resource, Action1: action1, Action2: action2}
+	return &ApprHandler{Log: writeLog, ApprService: apprService, ModelType: modelType, Keys: keys, Indexes: indexes, Offset: offset, Error: logError, Resource: resource, Action1: action1, Action2: action2, Status: d.GetServiceStatus(apprService)}
 }
 
 func (c *ApprHandler) Approve(ctx echo.Context) error {
@@ -74,11 +80,20 @@ func (c *ApprHandler) Approve(ctx echo.Context) error {
 		ctx.String(http.StatusBadRequest, er1.Error())
 		return er1
 	} else {
-		result, er2 := c.ApprService.Approve(r.Context(), id)
-		if er2 != nil {
+		review, er3 := d.DecodeReview(r)
+		if er3 != nil {
+			ctx.String(http.StatusBadRequest, er3.Error())
+			return er3
+		}
+		result, er2 := c.ApprService.Approve(d.WithReview(r.Context(), review), id)
+		var conflict *d.VersionConflict
+		if errors.As(er2, &conflict) {
+			respond(ctx, http.StatusConflict, conflict, c.Log, c.Resource, c.Action1, false, er2.Error())
+			return er2
+		} else if er2 != nil {
 			return handleError(ctx, http.StatusOK, internalServerError, c.Error, c.Resource, c.Action1, er2, c.Log)
 		} else {
-			return succeed(ctx, http.StatusOK, result, c.Log, c.Resource, c.Action1)
+			return succeed(ctx, d.GetReviewCode(c.Status, result), result, c.Log, c.Resource, c.Action1)
 		}
 	}
 }
@@ -90,15 +105,69 @@ func (c *ApprHandler) Reject(ctx echo.Context) error {
 		ctx.String(http.StatusBadRequest, er1.Error())
 		return er1
 	} else {
-		result, er2 := c.ApprService.Reject(r.Context(), id)
+		review, er3 := d.DecodeReview(r)
+		if er3 != nil {
+			ctx.String(http.StatusBadRequest, er3.Error())
+			return er3
+		}
+		result, er2 := c.ApprService.Reject(d.WithReview(r.Context(), review), id)
 		if er2 != nil {
 			return handleError(ctx, http.StatusOK, internalServerError, c.Error, c.Resource, c.Action2, er2, c.Log)
 		} else {
-			return succeed(ctx, http.StatusOK, result, c.Log, c.Resource, c.Action2)
+			return succeed(ctx, d.GetReviewCode(c.Status, result), result, c.Log, c.Resource, c.Action2)
 		}
 	}
 }
 
+// RequestChanges sends a staged change back to its author; the optional body is the review, as for Reject.
+func (c *ApprHandler) RequestChanges(ctx echo.Context) error {
+	if c.ChangeRequestService == nil {
+		return ctx.String(http.StatusNotImplemented, "RequestChanges is not supported")
+	}
+	r := ctx.Request()
+	id, er1 := d.BuildId(r, c.ModelType, c.Keys, c.Indexes, c.Offset)
+	if er1 != nil {
+		ctx.String(http.StatusBadRequest, er1.Error())
+		return er1
+	}
+	review, er2 := d.DecodeReview(r)
+	if er2 != nil {
+		ctx.String(http.StatusBadRequest, er2.Error())
+		return er2
+	}
+	result, er3 := c.ChangeRequestService.RequestChanges(d.WithReview(r.Context(), review), id)
+	if er3 != nil {
+		return handleError(ctx, http.StatusOK, internalServerError, c.Error, c.Resource, "request_changes", er3, c.Log)
+	}
+	return succeed(ctx, d.GetReviewCode(c.Status, result), result, c.Log, c.Resource, "request_changes")
+}
+
+// Comment adds a comment to the thread of a staged change; the body is {"comment": "..."}.
+func (c *ApprHandler) Comment(ctx echo.Context) error {
+	if c.CommentService == nil {
+		return ctx.String(http.StatusNotImplemented, "Comment is not supported")
+	}
+	r := ctx.Request()
+	id, er1 := d.BuildId(r, c.ModelType, c.Keys, c.Indexes, c.Offset)
+	if er1 != nil {
+		ctx.String(http.StatusBadRequest, er1.Error())
+		return er1
+	}
+	review, er2 := d.DecodeReview(r)
+	if er2 != nil {
+		ctx.String(http.StatusBadRequest, er2.Error())
+		return er2
+	}
+	if review == nil || len(review.Comment) == 0 {
+		return ctx.String(http.StatusBadRequest, "comment is required")
+	}
+	result, er3 := c.CommentService.Comment(r.Context(), id, review.Comment)
+	if er3 != nil {
+		return handleError(ctx, http.StatusOK, internalServerError, c.Error, c.Resource, "comment", er3, c.Log)
+	}
+	return succeed(ctx, d.GetReviewCode(c.Status, result), result, c.Log, c.Resource, "comment")
+}
+
 func respond(ctx echo.Context, code int, result interface{}, writeLog func(context.Context, string, string, bool, string) error, resource string, action string, success bool, desc string) error {
 	err := ctx.JSON(code, result)
 	if writeLog != nil {
@@ -106,6 +175,13 @@ func respond(ctx echo.Context, code int, result interface{}, writeLog func(conte
 	}
 	return err
 }
+func respondText(ctx echo.Context, code int, contentType string, content string, writeLog func(context.Context, string, string, bool, string) error, resource string, action string) error {
+	err := ctx.Blob(code, contentType, []byte(content))
+	if writeLog != nil {
+		writeLog(ctx.Request().Context(), resource, action, true, "")
+	}
+	return err
+}
 func handleError(ctx echo.Context, code int, result interface{}, logError func(context.Context, string), resource string, action string, err error, writeLog func(context.Context, string, string, bool, string) error) error {
 	if logError != nil {
 		logError(ctx.Request().Context(), err.Error())
@@ -77,3 +77,41 @@ func (c *ApprListHandler) Reject(ctx echo.Context) error {
 		}
 	}
 }
+
+// ApproveList responds the result of each id, including the version conflicts; it needs a service implementing ApprListResultService.
+func (c *ApprListHandler) ApproveList(ctx echo.Context) error {
+	s, ok := c.ApprListService.(d.ApprListResultService)
+	if !ok {
+		return ctx.String(http.StatusNotImplemented, "ApproveList is not supported")
+	}
+	r := ctx.Request()
+	ids, er1 := d.BuildIds(r, c.ModelType, c.Keys)
+	if er1 != nil {
+		ctx.String(http.StatusBadRequest, er1.Error())
+		return er1
+	}
+	results, er2 := s.ApproveList(r.Context(), ids)
+	if er2 != nil {
+		return handleError(ctx, http.StatusInternalServerError, internalServerError, c.Error, c.Resource, c.Action1, er2, c.Log)
+	}
+	return succeed(ctx, http.StatusOK, results, c.Log, c.Resource, c.Action1)
+}
+
+// RejectList responds the result of each id, including the version conflicts; it needs a service implementing ApprListResultService.
+func (c *ApprListHandler) RejectList(ctx echo.Context) error {
+	s, ok := c.ApprListService.(d.ApprListResultService)
+	if !ok {
+		return ctx.String(http.StatusNotImplemented, "RejectList is not supported")
+	}
+	r := ctx.Request()
+	ids, er1 := d.BuildIds(r, c.ModelType, c.Keys)
+	if er1 != nil {
+		ctx.String(http.StatusBadRequest, er1.Error())
+		return er1
+	}
+	results, er2 := s.RejectList(r.Context(), ids)
+	if er2 != nil {
+		return handleError(ctx, http.StatusInternalServerError, internalServerError, c.Error, c.Resource, c.Action2, er2, c.Log)
+	}
+	return succeed(ctx, http.StatusOK, results, c.Log, c.Resource, c.Action2)
+}
@@ -0,0 +1,45 @@
+package echo
+
+import (
+	"context"
+	d "github.com/core-go/diff"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+type DiffSearchHandler struct {
+	DiffSearchService d.DiffSearchService
+	Error             func(context.Context, string)
+	Log               func(ctx context.Context, resource string, action string, success bool, desc string) error
+	Resource          string
+	Action            string
+}
+
+func NewDiffSearchHandler(diffSearchService d.DiffSearchService, logError func(context.Context, string), writeLog func(context.Context, string, string, bool, string) error, options ...string) *DiffSearchHandler {
+	var resource, action string
+	if len(options) > 0 && len(options[0]) > 0 {
+		resource = options[0]
+	} else {
+		resource = "diff"
+	}
+	if len(options) > 1 && len(options[1]) > 0 {
+		action = options[1]
+	} else {
+		action = "search"
+	}
+	return &DiffSearchHandler{DiffSearchService: diffSearchService, Error: logError, Log: writeLog, Resource: resource, Action: action}
+}
+
+func (c *DiffSearchHandler) Search(ctx echo.Context) error {
+	r := ctx.Request()
+	filter, er1 := d.BuildDiffFilter(r)
+	if er1 != nil {
+		ctx.String(http.StatusBadRequest, er1.Error())
+		return er1
+	}
+	list, total, er2 := c.DiffSearchService.Search(r.Context(), filter)
+	if er2 != nil {
+		return handleError(ctx, http.StatusInternalServerError, internalServerError, c.Error, c.Resource, c.Action, er2, c.Log)
+	}
+	return succeed(ctx, http.StatusOK, d.SearchResult{List: list, Total: total}, c.Log, c.Resource, c.Action)
+}
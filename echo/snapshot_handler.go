@@ -0,0 +1,59 @@
+package echo
+
+import (
+	"context"
+	d "github.com/core-go/diff"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"reflect"
+	"time"
+)
+
+type SnapshotHandler struct {
+	SnapshotService d.SnapshotService
+	Keys            []string
+	ModelType       reflect.Type
+	Error           func(context.Context, string)
+	Indexes         map[string]int
+	Offset          int
+	Log             func(ctx context.Context, resource string, action string, success bool, desc string) error
+	Resource        string
+	Action          string
+}
+
+func NewSnapshotHandler(snapshotService d.SnapshotService, modelType reflect.Type, logError func(context.Context, string), writeLog func(context.Context, string, string, bool, string) error, options ...int) *SnapshotHandler {
+	return NewSnapshotHandlerWithKeys(snapshotService, nil, modelType, logError, writeLog, options...)
+}
+func NewSnapshotHandlerWithKeys(snapshotService d.SnapshotService, keys []string, modelType reflect.Type, logError func(context.Context, string), writeLog func(context.Context, string, string, bool, string) error, options ...int) *SnapshotHandler {
+	offset := 1
+	if len(options) > 0 && options[0] >= 0 {
+		offset = options[0]
+	}
+	if keys == nil || len(keys) == 0 {
+		keys = d.GetJsonPrimaryKeys(modelType)
+	}
+	indexes := d.GetIndexes(modelType)
+	resource := d.BuildResourceName(modelType.Name())
+	return &SnapshotHandler{Log: writeLog, SnapshotService: snapshotService, ModelType: modelType, Keys: keys, Indexes: indexes, Offset: offset, Error: logError, Resource: resource, Action: "snapshot"}
+}
+
+func (c *SnapshotHandler) Snapshot(ctx echo.Context) error {
+	r := ctx.Request()
+	id, er1 := d.BuildId(r, c.ModelType, c.Keys, c.Indexes, c.Offset)
+	if er1 != nil {
+		ctx.String(http.StatusBadRequest, er1.Error())
+		return er1
+	}
+	at, er2 := time.Parse(time.RFC3339, ctx.QueryParam("at"))
+	if er2 != nil {
+		ctx.String(http.StatusBadRequest, er2.Error())
+		return er2
+	}
+	result, er3 := c.SnapshotService.Snapshot(r.Context(), id, at)
+	if er3 != nil {
+		return handleError(ctx, http.StatusInternalServerError, internalServerError, c.Error, c.Resource, c.Action, er3, c.Log)
+	} else if result == nil {
+		return succeed(ctx, http.StatusNotFound, result, c.Log, c.Resource, c.Action)
+	}
+	return succeed(ctx, http.StatusOK, result, c.Log, c.Resource, c.Action)
+}
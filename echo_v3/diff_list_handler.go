@@ -71,6 +71,9 @@ func (c *DiffListHandler) DiffList(ctx echo.Context) error {
 					if len(result.By) > 0 {
 						m[c.Config.By] = result.By
 					}
+					if len(c.Config.Changes) > 0 {
+						m[c.Config.Changes] = result.Changes
+					}
 					l = append(l, m)
 				}
 				return succeed(ctx, http.StatusOK, l, c.Log, c.Resource, c.Action)
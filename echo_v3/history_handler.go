@@ -0,0 +1,56 @@
+package echo
+
+import (
+	"context"
+	d "github.com/core-go/diff"
+	"github.com/labstack/echo"
+	"net/http"
+	"reflect"
+)
+
+type HistoryHandler struct {
+	HistoryService d.HistoryService
+	Keys           []string
+	ModelType      reflect.Type
+	Error          func(context.Context, string)
+	Indexes        map[string]int
+	Offset         int
+	Log            func(ctx context.Context, resource string, action string, success bool, desc string) error
+	Resource       string
+	Action         string
+}
+
+func NewHistoryHandler(historyService d.HistoryService, modelType reflect.Type, logError func(context.Context, string), writeLog func(context.Context, string, string, bool, string) error, options ...int) *HistoryHandler {
+	return NewHistoryHandlerWithKeys(historyService, nil, modelType, logError, writeLog, options...)
+}
+func NewHistoryHandlerWithKeys(historyService d.HistoryService, keys []string, modelType reflect.Type, logError func(context.Context, string), writeLog func(context.Context, string, string, bool, string) error, options ...int) *HistoryHandler {
+	offset := 1
+	if len(options) > 0 && options[0] >= 0 {
+		offset = options[0]
+	}
+	if keys == nil || len(keys) == 0 {
+		keys = d.GetJsonPrimaryKeys(modelType)
+	}
+	indexes := d.GetIndexes(modelType)
+	resource := d.BuildResourceName(modelType.Name())
+	return &HistoryHandler{Log: writeLog, HistoryService: historyService, ModelType: modelType, Keys: keys, Indexes: indexes, Offset: offset, Error: logError, Resource: resource, Action: "history"}
+}
+
+func (c *HistoryHandler) Search(ctx echo.Context) error {
+	r := ctx.Request()
+	id, er1 := d.BuildId(r, c.ModelType, c.Keys, c.Indexes, c.Offset)
+	if er1 != nil {
+		ctx.String(http.StatusBadRequest, er1.Error())
+		return er1
+	}
+	filter, er2 := d.BuildHistoryFilter(r, id)
+	if er2 != nil {
+		ctx.String(http.StatusBadRequest, er2.Error())
+		return er2
+	}
+	list, total, er3 := c.HistoryService.Search(r.Context(), filter)
+	if er3 != nil {
+		return handleError(ctx, http.StatusInternalServerError, internalServerError, c.Error, c.Resource, c.Action, er3, c.Log)
+	}
+	return succeed(ctx, http.StatusOK, d.SearchResult{List: list, Total: total}, c.Log, c.Resource, c.Action)
+}
@@ -0,0 +1,79 @@
+package echo
+
+import (
+	"context"
+	"encoding/json"
+	d "github.com/core-go/diff"
+	"github.com/labstack/echo"
+	"net/http"
+	"reflect"
+)
+
+type SubmitHandler struct {
+	SubmitService d.SubmitService
+	Keys          []string
+	ModelType     reflect.Type
+	Error         func(context.Context, string)
+	Indexes       map[string]int
+	Fields        map[string]bool
+	Offset        int
+	Log           func(ctx context.Context, resource string, action string, success bool, desc string) error
+	Resource      string
+	Action        string
+}
+
+func NewSubmitHandler(submitService d.SubmitService, modelType reflect.Type, logError func(context.Context, string), writeLog func(context.Context, string, string, bool, string) error, options ...int) *SubmitHandler {
+	return NewSubmitHandlerWithKeys(submitService, nil, modelType, logError, writeLog, options...)
+}
+func NewSubmitHandlerWithKeys(submitService d.SubmitService, keys []string, modelType reflect.Type, logError func(context.Context, string), writeLog func(context.Context, string, string, bool, string) error, options ...int) *SubmitHandler {
+	offset := 1
+	if len(options) > 0 && options[0] >= 0 {
+		offset = options[0]
+	}
+	if keys == nil || len(keys) == 0 {
+		keys = d.GetJsonPrimaryKeys(modelType)
+	}
+	indexes := d.GetIndexes(modelType)
+	fields := d.GetJsonFields(modelType)
+	resource := d.BuildResourceName(modelType.Name())
+	return &SubmitHandler{Log: writeLog, SubmitService: submitService, ModelType: modelType, Keys: keys, Indexes: indexes, Fields: fields, Offset: offset, Error: logError, Resource: resource, Action: "submit"}
+}
+
+func (c *SubmitHandler) Submit(ctx echo.Context) error {
+	r := ctx.Request()
+	id, er1 := d.BuildId(r, c.ModelType, c.Keys, c.Indexes, c.Offset)
+	if er1 != nil {
+		ctx.String(http.StatusBadRequest, er1.Error())
+		return er1
+	}
+	var patch map[string]interface{}
+	er2 := json.NewDecoder(r.Body).Decode(&patch)
+	if er2 != nil {
+		ctx.String(http.StatusBadRequest, er2.Error())
+		return er2
+	}
+	er3 := d.CheckFields(c.Fields, patch)
+	if er3 != nil {
+		ctx.String(http.StatusBadRequest, er3.Error())
+		return er3
+	}
+	result, er4 := c.SubmitService.Submit(r.Context(), id, patch)
+	if er4 != nil {
+		return handleError(ctx, http.StatusInternalServerError, internalServerError, c.Error, c.Resource, c.Action, er4, c.Log)
+	}
+	return succeed(ctx, http.StatusOK, result, c.Log, c.Resource, c.Action)
+}
+
+func (c *SubmitHandler) Delete(ctx echo.Context) error {
+	r := ctx.Request()
+	id, er1 := d.BuildId(r, c.ModelType, c.Keys, c.Indexes, c.Offset)
+	if er1 != nil {
+		ctx.String(http.StatusBadRequest, er1.Error())
+		return er1
+	}
+	result, er2 := c.SubmitService.Delete(r.Context(), id)
+	if er2 != nil {
+		return handleError(ctx, http.StatusInternalServerError, internalServerError, c.Error, c.Resource, "delete", er2, c.Log)
+	}
+	return succeed(ctx, http.StatusOK, result, c.Log, c.Resource, "delete")
+}
@@ -0,0 +1,133 @@
+package diff
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+const (
+	ChangeAdded    = "added"
+	ChangeRemoved  = "removed"
+	ChangeModified = "modified"
+)
+
+type FieldChange struct {
+	Path string      `yaml:"path" mapstructure:"path" json:"path,omitempty" gorm:"column:path" bson:"path,omitempty" dynamodbav:"path,omitempty" firestore:"path,omitempty"`
+	Old  interface{} `yaml:"old" mapstructure:"old" json:"old,omitempty" gorm:"column:old" bson:"old,omitempty" dynamodbav:"old,omitempty" firestore:"old,omitempty"`
+	New  interface{} `yaml:"new" mapstructure:"new" json:"new,omitempty" gorm:"column:new" bson:"new,omitempty" dynamodbav:"new,omitempty" firestore:"new,omitempty"`
+	Kind string      `yaml:"kind" mapstructure:"kind" json:"kind,omitempty" gorm:"column:kind" bson:"kind,omitempty" dynamodbav:"kind,omitempty" firestore:"kind,omitempty"`
+}
+
+// BuildChanges compares origin and value field by field; nested objects are compared recursively, arrays by index.
+func BuildChanges(origin interface{}, value interface{}) []FieldChange {
+	changes := make([]FieldChange, 0)
+	o := toJsonValue(origin)
+	v := toJsonValue(value)
+	if o == nil {
+		o = map[string]interface{}{}
+	}
+	if v == nil {
+		v = map[string]interface{}{}
+	}
+	compareValues("", o, v, &changes)
+	return changes
+}
+
+func compareValues(path string, origin interface{}, value interface{}, changes *[]FieldChange) {
+	switch o := origin.(type) {
+	case map[string]interface{}:
+		if v, ok := value.(map[string]interface{}); ok {
+			keys := make([]string, 0)
+			for k := range o {
+				keys = append(keys, k)
+			}
+			for k := range v {
+				if _, exist := o[k]; !exist {
+					keys = append(keys, k)
+				}
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				p := k
+				if len(path) > 0 {
+					p = path + "." + k
+				}
+				ov, ok1 := o[k]
+				vv, ok2 := v[k]
+				if !ok2 {
+					*changes = append(*changes, FieldChange{Path: p, Old: ov, Kind: ChangeRemoved})
+				} else if !ok1 {
+					*changes = append(*changes, FieldChange{Path: p, New: vv, Kind: ChangeAdded})
+				} else {
+					compareValues(p, ov, vv, changes)
+				}
+			}
+			return
+		}
+	case []interface{}:
+		if v, ok := value.([]interface{}); ok {
+			for i := 0; i < len(o) || i < len(v); i++ {
+				p := path + "[" + strconv.Itoa(i) + "]"
+				if i >= len(v) {
+					*changes = append(*changes, FieldChange{Path: p, Old: o[i], Kind: ChangeRemoved})
+				} else if i >= len(o) {
+					*changes = append(*changes, FieldChange{Path: p, New: v[i], Kind: ChangeAdded})
+				} else {
+					compareValues(p, o[i], v[i], changes)
+				}
+			}
+			return
+		}
+	}
+	if !equalValues(origin, value) {
+		*changes = append(*changes, FieldChange{Path: path, Old: origin, New: value, Kind: ChangeModified})
+	}
+}
+
+func equalValues(a interface{}, b interface{}) bool {
+	x, ok1 := toFloat(a)
+	y, ok2 := toFloat(b)
+	if ok1 && ok2 {
+		return x == y
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+func toJsonValue(v interface{}) interface{} {
+	switch m := v.(type) {
+	case *map[string]interface{}:
+		if m == nil {
+			return nil
+		}
+		return *m
+	case *[]interface{}:
+		if m == nil {
+			return nil
+		}
+		return *m
+	default:
+		return v
+	}
+}
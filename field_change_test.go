@@ -0,0 +1,44 @@
+package diff_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	d "github.com/core-go/diff"
+)
+
+func TestBuildChanges(t *testing.T) {
+	tests := []struct {
+		name    string
+		origin  string
+		value   string
+		changes []string
+	}{
+		{"no change", `{"a":1,"b":"x"}`, `{"b":"x","a":1}`, []string{}},
+		{"modified, added and removed fields", `{"a":1,"b":"x"}`, `{"a":2,"c":true}`, []string{"modified a 1 2", "removed b x <nil>", "added c <nil> true"}},
+		{"a create", `null`, `{"a":1}`, []string{"added a <nil> 1"}},
+		{"a delete", `{"a":1}`, `null`, []string{"removed a 1 <nil>"}},
+		{"nested objects", `{"x":{"a":1,"b":1}}`, `{"x":{"a":1,"b":2}}`, []string{"modified x.b 1 2"}},
+		{"arrays by index", `{"l":[1,2]}`, `{"l":[1,3,4]}`, []string{"modified l[1] 2 3", "added l[2] <nil> 4"}},
+		{"a shorter array", `{"l":[1,2]}`, `{"l":[1]}`, []string{"removed l[1] 2 <nil>"}},
+		{"a type change", `{"a":{"b":1}}`, `{"a":"b"}`, []string{"modified a map[b:1] b"}},
+		{"a null field", `{"a":1}`, `{"a":null}`, []string{"modified a 1 <nil>"}},
+	}
+	for _, test := range tests {
+		changes := make([]string, 0)
+		for _, change := range d.BuildChanges(decode(t, test.origin), decode(t, test.value)) {
+			changes = append(changes, fmt.Sprintf("%s %s %v %v", change.Kind, change.Path, change.Old, change.New))
+		}
+		if !reflect.DeepEqual(changes, test.changes) {
+			t.Errorf("%s: changes = %q, want %q", test.name, changes, test.changes)
+		}
+	}
+}
+
+func TestBuildChangesComparesNumbersByValue(t *testing.T) {
+	changes := d.BuildChanges(map[string]interface{}{"a": int64(1), "b": 1.5}, map[string]interface{}{"a": 1.0, "b": "1.5"})
+	if len(changes) != 1 || changes[0].Path != "b" {
+		t.Errorf("changes = %+v, want only b, whose type changed", changes)
+	}
+}
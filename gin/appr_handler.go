@@ -2,6 +2,7 @@ package gin
 
 import (
 	"context"
+	"errors"
 	d "github.com/core-go/diff"
 	"github.com/gin-gonic/gin"
 	"net/http"
@@ -21,6 +22,11 @@ type ApprHandler struct {
 	Resource    string
 	Action1     string
 	Action2     string
+	Status      d.StatusConfig
+	// CommentService, when set, serves Comment
+	CommentService d.CommentService
+	// ChangeRequestService, when set, serves RequestChanges
+	ChangeRequestService d.ChangeRequestService
 }
 
 func NewApprHandler(apprService d.ApprService, modelType reflect.Type, logError func(context.Context, string), option ...int) *ApprHandler {
@@ -64,7 +70,7 @@ func NewApprHandlerWithKeysAndLog(apprService d.ApprService, keys []string, mode
 	} else {
 		resource = d.BuildResourceName(modelType.Name())
 	}
-	return &ApprHandler{Log: writeLog, ApprService: apprService, ModelType: modelType, Keys: keys, Indexes: indexes, Offset: offset, Error: logError, Resource: resource, Action1: action1, Action2: action2}
+	return &ApprHandler{Log: writeLog, ApprService: apprService, ModelType: modelType, Keys: keys, Indexes: indexes, Offset: offset, Error: logError, Resource: resource, Action1: action1, Action2: action2, Status: d.GetServiceStatus(apprService)}
 }
 
 func (c *ApprHandler) Approve(ctx *gin.Context) {
@@ -73,11 +79,19 @@ func (c *ApprHandler) Approve(ctx *gin.Context) {
 	if er1 != nil {
 		ctx.String(http.StatusBadRequest, er1.Error())
 	} else {
-		result, er2 := c.ApprService.Approve(r.Context(), id)
-		if er2 != nil {
+		review, er3 := d.DecodeReview(r)
+		if er3 != nil {
+			ctx.String(http.StatusBadRequest, er3.Error())
+			return
+		}
+		result, er2 := c.ApprService.Approve(d.WithReview(r.Context(), review), id)
+		var conflict *d.VersionConflict
+		if errors.As(er2, &conflict) {
+			respond(ctx, http.StatusConflict, conflict, c.Log, c.Resource, c.Action1, false, er2.Error())
+		} else if er2 != nil {
 			handleError(ctx, http.StatusOK, internalServerError, c.Error, c.Resource, c.Action1, er2, c.Log)
 		} else {
-			succeed(ctx, http.StatusOK, result, c.Log, c.Resource, c.Action1)
+			succeed(ctx, d.GetReviewCode(c.Status, result), result, c.Log, c.Resource, c.Action1)
 		}
 	}
 }
@@ -88,21 +102,86 @@ func (c *ApprHandler) Reject(ctx *gin.Context) {
 	if er1 != nil {
 		ctx.String(http.StatusBadRequest, er1.Error())
 	} else {
-		result, er2 := c.ApprService.Reject(r.Context(), id)
+		review, er3 := d.DecodeReview(r)
+		if er3 != nil {
+			ctx.String(http.StatusBadRequest, er3.Error())
+			return
+		}
+		result, er2 := c.ApprService.Reject(d.WithReview(r.Context(), review), id)
 		if er2 != nil {
 			handleError(ctx, http.StatusOK, internalServerError, c.Error, c.Resource, c.Action2, er2, c.Log)
 		} else {
-			succeed(ctx, http.StatusOK, result, c.Log, c.Resource, c.Action2)
+			succeed(ctx, d.GetReviewCode(c.Status, result), result, c.Log, c.Resource, c.Action2)
 		}
 	}
 }
 
+// RequestChanges sends a staged change back to its author; the optional body is the review, as for Reject.
+func (c *ApprHandler) RequestChanges(ctx *gin.Context) {
+	if c.ChangeRequestService == nil {
+		ctx.String(http.StatusNotImplemented, "RequestChanges is not supported")
+		return
+	}
+	r := ctx.Request
+	id, er1 := d.BuildId(r, c.ModelType, c.Keys, c.Indexes, c.Offset)
+	if er1 != nil {
+		ctx.String(http.StatusBadRequest, er1.Error())
+		return
+	}
+	review, er2 := d.DecodeReview(r)
+	if er2 != nil {
+		ctx.String(http.StatusBadRequest, er2.Error())
+		return
+	}
+	result, er3 := c.ChangeRequestService.RequestChanges(d.WithReview(r.Context(), review), id)
+	if er3 != nil {
+		handleError(ctx, http.StatusOK, internalServerError, c.Error, c.Resource, "request_changes", er3, c.Log)
+	} else {
+		succeed(ctx, d.GetReviewCode(c.Status, result), result, c.Log, c.Resource, "request_changes")
+	}
+}
+
+// Comment adds a comment to the thread of a staged change; the body is {"comment": "..."}.
+func (c *ApprHandler) Comment(ctx *gin.Context) {
+	if c.CommentService == nil {
+		ctx.String(http.StatusNotImplemented, "Comment is not supported")
+		return
+	}
+	r := ctx.Request
+	id, er1 := d.BuildId(r, c.ModelType, c.Keys, c.Indexes, c.Offset)
+	if er1 != nil {
+		ctx.String(http.StatusBadRequest, er1.Error())
+		return
+	}
+	review, er2 := d.DecodeReview(r)
+	if er2 != nil {
+		ctx.String(http.StatusBadRequest, er2.Error())
+		return
+	}
+	if review == nil || len(review.Comment) == 0 {
+		ctx.String(http.StatusBadRequest, "comment is required")
+		return
+	}
+	result, er3 := c.CommentService.Comment(r.Context(), id, review.Comment)
+	if er3 != nil {
+		handleError(ctx, http.StatusOK, internalServerError, c.Error, c.Resource, "comment", er3, c.Log)
+	} else {
+		succeed(ctx, d.GetReviewCode(c.Status, result), result, c.Log, c.Resource, "comment")
+	}
+}
+
 func respond(ctx *gin.Context, code int, result interface{}, writeLog func(context.Context, string, string, bool, string) error, resource string, action string, success bool, desc string) {
 	ctx.JSON(code, result)
 	if writeLog != nil {
 		writeLog(ctx.Request.Context(), resource, action, success, desc)
 	}
 }
+func respondText(ctx *gin.Context, code int, contentType string, content string, writeLog func(context.Context, string, string, bool, string) error, resource string, action string) {
+	ctx.Data(code, contentType, []byte(content))
+	if writeLog != nil {
+		writeLog(ctx.Request.Context(), resource, action, true, "")
+	}
+}
 func handleError(ctx *gin.Context, code int, result interface{}, logError func(context.Context, string), resource string, action string, err error, writeLog func(context.Context, string, string, bool, string) error) {
 	if logError != nil {
 		logError(ctx.Request.Context(), err.Error())
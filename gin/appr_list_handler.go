@@ -75,3 +75,45 @@ func (c *ApprListHandler) Reject(ctx *gin.Context) {
 		}
 	}
 }
+
+// ApproveList responds the result of each id, including the version conflicts; it needs a service implementing ApprListResultService.
+func (c *ApprListHandler) ApproveList(ctx *gin.Context) {
+	s, ok := c.ApprListService.(d.ApprListResultService)
+	if !ok {
+		ctx.String(http.StatusNotImplemented, "ApproveList is not supported")
+		return
+	}
+	r := ctx.Request
+	ids, er1 := d.BuildIds(r, c.ModelType, c.Keys)
+	if er1 != nil {
+		ctx.String(http.StatusBadRequest, er1.Error())
+		return
+	}
+	results, er2 := s.ApproveList(r.Context(), ids)
+	if er2 != nil {
+		handleError(ctx, http.StatusInternalServerError, internalServerError, c.Error, c.Resource, c.Action1, er2, c.Log)
+	} else {
+		succeed(ctx, http.StatusOK, results, c.Log, c.Resource, c.Action1)
+	}
+}
+
+// RejectList responds the result of each id, including the version conflicts; it needs a service implementing ApprListResultService.
+func (c *ApprListHandler) RejectList(ctx *gin.Context) {
+	s, ok := c.ApprListService.(d.ApprListResultService)
+	if !ok {
+		ctx.String(http.StatusNotImplemented, "RejectList is not supported")
+		return
+	}
+	r := ctx.Request
+	ids, er1 := d.BuildIds(r, c.ModelType, c.Keys)
+	if er1 != nil {
+		ctx.String(http.StatusBadRequest, er1.Error())
+		return
+	}
+	results, er2 := s.RejectList(r.Context(), ids)
+	if er2 != nil {
+		handleError(ctx, http.StatusInternalServerError, internalServerError, c.Error, c.Resource, c.Action2, er2, c.Log)
+	} else {
+		succeed(ctx, http.StatusOK, results, c.Log, c.Resource, c.Action2)
+	}
+}
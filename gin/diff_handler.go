@@ -19,6 +19,7 @@ type DiffHandler struct {
 	Resource  string
 	Action    string
 	Config    *d.DiffModelConfig
+	Renderer  *d.Renderer
 }
 
 func NewDiffHandler(diff func(context.Context, interface{}) (*d.DiffModel, error), modelType reflect.Type, logError func(context.Context, string), config *d.DiffModelConfig, writeLog func(context.Context, string, string, bool, string) error, options ...int) *DiffHandler {
@@ -44,7 +45,7 @@ func NewDiffHandlerWithKeys(diff func(context.Context, interface{}) (*d.DiffMode
 	if len(action) == 0 {
 		action = "diff"
 	}
-	return &DiffHandler{Log: writeLog, GetDiff: diff, ModelType: modelType, Keys: keys, Indexes: indexes, Resource: resource, Offset: offset, Config: config, Error: logError}
+	return &DiffHandler{Log: writeLog, GetDiff: diff, ModelType: modelType, Keys: keys, Indexes: indexes, Resource: resource, Offset: offset, Config: config, Error: logError, Renderer: d.NewRenderer(modelType)}
 }
 
 func (c *DiffHandler) Diff(ctx *gin.Context) {
@@ -56,8 +57,15 @@ func (c *DiffHandler) Diff(ctx *gin.Context) {
 		result, er2 := c.GetDiff(r.Context(), id)
 		if er2 != nil {
 			handleError(ctx, http.StatusInternalServerError, internalServerError, c.Error, c.Resource, c.Action, er2, c.Log)
+		} else if result == nil {
+			succeed(ctx, http.StatusNotFound, result, c.Log, c.Resource, c.Action)
 		} else {
-			if c.Config == nil {
+			if format := d.GetRenderFormat(r); len(format) > 0 && c.Renderer != nil {
+				respondText(ctx, http.StatusOK, d.GetRenderContentType(format), c.Renderer.Render(*result, format), c.Log, c.Resource, c.Action)
+			} else if d.IsJsonPatch(r) {
+				ctx.Header("Content-Type", d.ContentTypeJsonPatch)
+				succeed(ctx, http.StatusOK, d.BuildJsonPatch(result.Origin, result.Value), c.Log, c.Resource, c.Action)
+			} else if c.Config == nil {
 				succeed(ctx, http.StatusOK, result, c.Log, c.Resource, c.Action)
 			} else {
 				m := make(map[string]interface{})
@@ -73,6 +81,9 @@ func (c *DiffHandler) Diff(ctx *gin.Context) {
 				if len(result.By) > 0 {
 					m[c.Config.By] = result.By
 				}
+				if len(c.Config.Changes) > 0 {
+					m[c.Config.Changes] = result.Changes
+				}
 				succeed(ctx, http.StatusOK, m, c.Log, c.Resource, c.Action)
 			}
 		}
@@ -70,6 +70,9 @@ func (c *DiffListHandler) DiffList(ctx *gin.Context) {
 					if len(result.By) > 0 {
 						m[c.Config.By] = result.By
 					}
+					if len(c.Config.Changes) > 0 {
+						m[c.Config.Changes] = result.Changes
+					}
 					l = append(l, m)
 				}
 				succeed(ctx, http.StatusOK, l, c.Log, c.Resource, c.Action)
@@ -0,0 +1,54 @@
+package diff
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+)
+
+type HistoryHandler struct {
+	HistoryService HistoryService
+	Keys           []string
+	ModelType      reflect.Type
+	Error          func(context.Context, string)
+	Indexes        map[string]int
+	Offset         int
+	Log            func(ctx context.Context, resource string, action string, success bool, desc string) error
+	Resource       string
+	Action         string
+}
+
+func NewHistoryHandler(historyService HistoryService, modelType reflect.Type, logError func(context.Context, string), writeLog func(context.Context, string, string, bool, string) error, options ...int) *HistoryHandler {
+	return NewHistoryHandlerWithKeys(historyService, nil, modelType, logError, writeLog, options...)
+}
+func NewHistoryHandlerWithKeys(historyService HistoryService, keys []string, modelType reflect.Type, logError func(context.Context, string), writeLog func(context.Context, string, string, bool, string) error, options ...int) *HistoryHandler {
+	offset := 1
+	if len(options) > 0 && options[0] >= 0 {
+		offset = options[0]
+	}
+	if keys == nil || len(keys) == 0 {
+		keys = GetJsonPrimaryKeys(modelType)
+	}
+	indexes := GetIndexes(modelType)
+	resource := BuildResourceName(modelType.Name())
+	return &HistoryHandler{Log: writeLog, HistoryService: historyService, ModelType: modelType, Keys: keys, Indexes: indexes, Offset: offset, Error: logError, Resource: resource, Action: "history"}
+}
+
+func (c *HistoryHandler) Search(w http.ResponseWriter, r *http.Request) {
+	id, er1 := BuildId(r, c.ModelType, c.Keys, c.Indexes, c.Offset)
+	if er1 != nil {
+		http.Error(w, er1.Error(), http.StatusBadRequest)
+		return
+	}
+	filter, er2 := BuildHistoryFilter(r, id)
+	if er2 != nil {
+		http.Error(w, er2.Error(), http.StatusBadRequest)
+		return
+	}
+	list, total, er3 := c.HistoryService.Search(r.Context(), filter)
+	if er3 != nil {
+		handleError(w, r, http.StatusInternalServerError, internalServerError, c.Error, c.Resource, c.Action, er3, c.Log)
+	} else {
+		succeed(w, r, http.StatusOK, SearchResult{List: list, Total: total}, c.Log, c.Resource, c.Action)
+	}
+}
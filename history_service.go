@@ -0,0 +1,70 @@
+package diff
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type HistoryFilter struct {
+	Id         interface{} `yaml:"id" mapstructure:"id" json:"id,omitempty" gorm:"column:id" bson:"_id,omitempty" dynamodbav:"id,omitempty" firestore:"id,omitempty"`
+	By         string      `yaml:"by" mapstructure:"by" json:"by,omitempty" gorm:"column:by" bson:"by,omitempty" dynamodbav:"by,omitempty" firestore:"by,omitempty"`
+	ApprovedBy string      `yaml:"approved_by" mapstructure:"approved_by" json:"approvedBy,omitempty" gorm:"column:approvedby" bson:"approvedBy,omitempty" dynamodbav:"approvedBy,omitempty" firestore:"approvedBy,omitempty"`
+	From       *time.Time  `yaml:"from" mapstructure:"from" json:"from,omitempty" gorm:"column:from" bson:"from,omitempty" dynamodbav:"from,omitempty" firestore:"from,omitempty"`
+	To         *time.Time  `yaml:"to" mapstructure:"to" json:"to,omitempty" gorm:"column:to" bson:"to,omitempty" dynamodbav:"to,omitempty" firestore:"to,omitempty"`
+	State      string      `yaml:"state" mapstructure:"state" json:"state,omitempty" gorm:"column:state" bson:"state,omitempty" dynamodbav:"state,omitempty" firestore:"state,omitempty"`
+	Page       int64       `yaml:"page" mapstructure:"page" json:"page,omitempty" gorm:"column:page" bson:"page,omitempty" dynamodbav:"page,omitempty" firestore:"page,omitempty"`
+	Limit      int64       `yaml:"limit" mapstructure:"limit" json:"limit,omitempty" gorm:"column:limit" bson:"limit,omitempty" dynamodbav:"limit,omitempty" firestore:"limit,omitempty"`
+}
+
+type SearchResult struct {
+	List  []DiffModel `yaml:"list" mapstructure:"list" json:"list" gorm:"column:list" bson:"list" dynamodbav:"list" firestore:"list"`
+	Total int64       `yaml:"total" mapstructure:"total" json:"total" gorm:"column:total" bson:"total" dynamodbav:"total" firestore:"total"`
+}
+
+type HistoryService interface {
+	Search(ctx context.Context, filter HistoryFilter) ([]DiffModel, int64, error)
+}
+
+type SnapshotService interface {
+	Snapshot(ctx context.Context, id interface{}, at time.Time) (interface{}, error)
+}
+
+// BuildHistoryFilter reads by, approvedBy, from, to (RFC 3339), page and limit from the query string.
+func BuildHistoryFilter(r *http.Request, id interface{}) (HistoryFilter, error) {
+	q := r.URL.Query()
+	filter := HistoryFilter{Id: id, By: q.Get("by"), ApprovedBy: q.Get("approvedBy"), State: q.Get("state")}
+	var err error
+	if filter.From, err = parseTime(q.Get("from")); err != nil {
+		return filter, err
+	}
+	if filter.To, err = parseTime(q.Get("to")); err != nil {
+		return filter, err
+	}
+	if filter.Page, err = parseInt(q.Get("page")); err != nil {
+		return filter, err
+	}
+	if filter.Limit, err = parseInt(q.Get("limit")); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
+
+func parseTime(s string) (*time.Time, error) {
+	if len(s) == 0 {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func parseInt(s string) (int64, error) {
+	if len(s) == 0 {
+		return 0, nil
+	}
+	return strconv.ParseInt(s, 10, 64)
+}
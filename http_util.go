@@ -7,6 +7,7 @@ import (
 	"io"
 	"net/http"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -180,6 +181,35 @@ func GetIndexes(modelType reflect.Type) map[string]int {
 	}
 	return mapJsonNameIndex
 }
+// GetJsonFields returns the json names of the fields of the model.
+func GetJsonFields(modelType reflect.Type) map[string]bool {
+	if modelType.Kind() == reflect.Ptr {
+		modelType = modelType.Elem()
+	}
+	fields := make(map[string]bool)
+	for i := 0; i < modelType.NumField(); i++ {
+		name := strings.Split(modelType.Field(i).Tag.Get("json"), ",")[0]
+		if len(name) > 0 && name != "-" {
+			fields[name] = true
+		}
+	}
+	return fields
+}
+
+// CheckFields returns an error for the first name of the patch, in order, which is not one of the fields.
+func CheckFields(fields map[string]bool, patch map[string]interface{}) error {
+	names := make([]string, 0)
+	for name := range patch {
+		if !fields[name] {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	sort.Strings(names)
+	return fmt.Errorf("%s is not a field", names[0])
+}
 func getParamIds(r *http.Request, idNames []string, options... int) (interface{}, map[string]string, error) {
 	offset := 0
 	if len(options) > 0 && options[0] > 0 {
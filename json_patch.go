@@ -0,0 +1,99 @@
+package diff
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const ContentTypeJsonPatch = "application/json-patch+json"
+
+type PatchOperation struct {
+	Op    string      `yaml:"op" mapstructure:"op" json:"op" gorm:"column:op" bson:"op" dynamodbav:"op" firestore:"op"`
+	Path  string      `yaml:"path" mapstructure:"path" json:"path" gorm:"column:path" bson:"path" dynamodbav:"path" firestore:"path"`
+	Value interface{} `yaml:"value" mapstructure:"value" json:"value,omitempty" gorm:"column:value" bson:"value,omitempty" dynamodbav:"value,omitempty" firestore:"value,omitempty"`
+}
+
+func (o PatchOperation) MarshalJSON() ([]byte, error) {
+	m := map[string]interface{}{"op": o.Op, "path": o.Path}
+	if o.Op == "add" || o.Op == "replace" || o.Op == "test" {
+		m["value"] = o.Value
+	}
+	return json.Marshal(m)
+}
+
+func IsJsonPatch(r *http.Request) bool {
+	if r.URL != nil && r.URL.Query().Get("format") == "json-patch" {
+		return true
+	}
+	return strings.Contains(r.Header.Get("Accept"), ContentTypeJsonPatch)
+}
+
+// BuildJsonPatch returns the RFC 6902 operations which transform origin into value.
+func BuildJsonPatch(origin interface{}, value interface{}) []PatchOperation {
+	patch := make([]PatchOperation, 0)
+	o := toJsonValue(origin)
+	v := toJsonValue(value)
+	if o == nil {
+		o = map[string]interface{}{}
+	}
+	if v == nil {
+		v = map[string]interface{}{}
+	}
+	buildPatch("", o, v, &patch)
+	return patch
+}
+
+func buildPatch(path string, origin interface{}, value interface{}, patch *[]PatchOperation) {
+	switch o := origin.(type) {
+	case map[string]interface{}:
+		if v, ok := value.(map[string]interface{}); ok {
+			keys := make([]string, 0)
+			for k := range o {
+				keys = append(keys, k)
+			}
+			for k := range v {
+				if _, exist := o[k]; !exist {
+					keys = append(keys, k)
+				}
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				p := path + "/" + escapePointer(k)
+				ov, ok1 := o[k]
+				vv, ok2 := v[k]
+				if !ok2 {
+					*patch = append(*patch, PatchOperation{Op: "remove", Path: p})
+				} else if !ok1 {
+					*patch = append(*patch, PatchOperation{Op: "add", Path: p, Value: vv})
+				} else {
+					buildPatch(p, ov, vv, patch)
+				}
+			}
+			return
+		}
+	case []interface{}:
+		if v, ok := value.([]interface{}); ok {
+			i := 0
+			for ; i < len(o) && i < len(v); i++ {
+				buildPatch(path+"/"+strconv.Itoa(i), o[i], v[i], patch)
+			}
+			for j := len(o) - 1; j >= i; j-- {
+				*patch = append(*patch, PatchOperation{Op: "remove", Path: path + "/" + strconv.Itoa(j)})
+			}
+			for ; i < len(v); i++ {
+				*patch = append(*patch, PatchOperation{Op: "add", Path: path + "/-", Value: v[i]})
+			}
+			return
+		}
+	}
+	if !equalValues(origin, value) {
+		*patch = append(*patch, PatchOperation{Op: "replace", Path: path, Value: value})
+	}
+}
+
+func escapePointer(s string) string {
+	return strings.Replace(strings.Replace(s, "~", "~0", -1), "/", "~1", -1)
+}
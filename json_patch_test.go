@@ -0,0 +1,61 @@
+package diff_test
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	d "github.com/core-go/diff"
+)
+
+func TestBuildJsonPatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		origin string
+		value  string
+		patch  string
+	}{
+		{"adding an object member", `{"foo":"bar"}`, `{"baz":"qux","foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`},
+		{"removing an object member", `{"baz":"qux","foo":"bar"}`, `{"foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`},
+		{"replacing a value", `{"baz":"qux","foo":"bar"}`, `{"baz":"boo","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`},
+		{"adding a nested member object", `{"foo":"bar"}`, `{"foo":"bar","child":{"grandchild":{}}}`, `[{"op":"add","path":"/child","value":{"grandchild":{}}}]`},
+		{"replacing with null", `{"foo":"bar"}`, `{"foo":null}`, `[{"op":"replace","path":"/foo","value":null}]`},
+		{"changing an array element", `{"foo":["bar","baz"]}`, `{"foo":["bar","qux"]}`, `[{"op":"replace","path":"/foo/1","value":"qux"}]`},
+		{"appending to an array", `{"foo":["bar"]}`, `{"foo":["bar","baz","qux"]}`, `[{"op":"add","path":"/foo/-","value":"baz"},{"op":"add","path":"/foo/-","value":"qux"}]`},
+		{"removing array elements from the end", `{"foo":["bar","qux","baz"]}`, `{"foo":["bar"]}`, `[{"op":"remove","path":"/foo/2"},{"op":"remove","path":"/foo/1"}]`},
+		{"escaping ~ and /", `{"a/b":1,"m~n":1}`, `{"a/b":2,"m~n":2}`, `[{"op":"replace","path":"/a~1b","value":2},{"op":"replace","path":"/m~0n","value":2}]`},
+		{"a create", `null`, `{"foo":1}`, `[{"op":"add","path":"/foo","value":1}]`},
+		{"no change", `{"foo":[1,{"a":1}]}`, `{"foo":[1,{"a":1}]}`, `[]`},
+	}
+	for _, test := range tests {
+		b, err := json.Marshal(d.BuildJsonPatch(decode(t, test.origin), decode(t, test.value)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != test.patch {
+			t.Errorf("%s: patch = %s, want %s", test.name, b, test.patch)
+		}
+	}
+}
+
+func TestIsJsonPatch(t *testing.T) {
+	tests := []struct {
+		url    string
+		accept string
+		want   bool
+	}{
+		{"/users/u1/diff", "", false},
+		{"/users/u1/diff?format=json-patch", "", true},
+		{"/users/u1/diff", "application/json-patch+json", true},
+		{"/users/u1/diff", "application/json", false},
+	}
+	for _, test := range tests {
+		r := httptest.NewRequest("GET", test.url, nil)
+		if len(test.accept) > 0 {
+			r.Header.Set("Accept", test.accept)
+		}
+		if got := d.IsJsonPatch(r); got != test.want {
+			t.Errorf("IsJsonPatch(%s, %q) = %v, want %v", test.url, test.accept, got, test.want)
+		}
+	}
+}
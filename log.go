@@ -7,7 +7,9 @@ import (
 )
 
 func respond(w http.ResponseWriter, r *http.Request, code int, result interface{}, writeLog func(context.Context, string, string, bool, string) error, resource string, action string, success bool, desc string) {
-	w.Header().Set("Content-Type", "application/json")
+	if len(w.Header().Get("Content-Type")) == 0 {
+		w.Header().Set("Content-Type", "application/json")
+	}
 	w.WriteHeader(code)
 	err := json.NewEncoder(w).Encode(result)
 	if err != nil {
@@ -26,3 +28,11 @@ func handleError(w http.ResponseWriter, r *http.Request, code int, result interf
 func succeed(w http.ResponseWriter, r *http.Request, code int, result interface{}, writeLog func(context.Context, string, string, bool, string) error, resource string, action string) {
 	respond(w, r, code, result, writeLog, resource, action, true, "")
 }
+func respondText(w http.ResponseWriter, r *http.Request, code int, contentType string, content string, writeLog func(context.Context, string, string, bool, string) error, resource string, action string) {
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(code)
+	w.Write([]byte(content))
+	if writeLog != nil {
+		writeLog(r.Context(), resource, action, true, "")
+	}
+}
@@ -0,0 +1,381 @@
+package diff
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps records, staged changes and history in memory; it is safe for concurrent use.
+type MemoryStore struct {
+	EntityType        string
+	IdNames           []string
+	Version           string
+	Status            StatusConfig
+	KeyBuilder        KeyBuilder
+	GetUser           func(context.Context) string
+	AllowSelfApproval bool
+	// KeepRejected writes rejected changes to the history with the state rejected, as SqlApprover does
+	KeepRejected bool
+	mu           sync.RWMutex
+	records      map[string]map[string]interface{}
+	staged       map[string]DiffModel
+	histories    []DiffModel
+}
+
+type MemoryDiffListReader struct {
+	Store *MemoryStore
+}
+
+type MemoryApprListService struct {
+	Store *MemoryStore
+}
+
+func NewMemoryStore(entityType string, idNames []string, status *StatusConfig, keyBuilder KeyBuilder, getUser func(context.Context) string, options ...string) *MemoryStore {
+	var version string
+	if len(options) > 0 {
+		version = options[0]
+	}
+	return &MemoryStore{EntityType: entityType, IdNames: idNames, Version: version, Status: InitializeStatus(status), KeyBuilder: keyBuilder, GetUser: getUser,
+		records: make(map[string]map[string]interface{}), staged: make(map[string]DiffModel)}
+}
+
+func (s *MemoryStore) GetStatus() StatusConfig {
+	return s.Status
+}
+
+func (s MemoryApprListService) GetStatus() StatusConfig {
+	return s.Store.Status
+}
+
+func NewMemoryDiffListReader(store *MemoryStore) *MemoryDiffListReader {
+	return &MemoryDiffListReader{Store: store}
+}
+
+func NewMemoryApprListService(store *MemoryStore) *MemoryApprListService {
+	return &MemoryApprListService{Store: store}
+}
+
+// Put sets the live record without staging, to seed the store.
+func (s *MemoryStore) Put(id interface{}, record map[string]interface{}) error {
+	key, _, err := buildKeys(s.KeyBuilder, s.IdNames, id)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[fmt.Sprint(key)] = normalizeJson(record)
+	return nil
+}
+
+func (s *MemoryStore) Get(id interface{}) (map[string]interface{}, error) {
+	key, _, err := buildKeys(s.KeyBuilder, s.IdNames, id)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	record, ok := s.records[fmt.Sprint(key)]
+	if !ok {
+		return nil, nil
+	}
+	return normalizeJson(record), nil
+}
+
+func (s *MemoryStore) Submit(ctx context.Context, id interface{}, patch map[string]interface{}) (int, error) {
+	return s.submit(ctx, id, func(origin map[string]interface{}, keys map[string]interface{}) (map[string]interface{}, bool) {
+		value, _ := MergePatch(origin, patch).(map[string]interface{})
+		if origin == nil {
+			for k, v := range keys {
+				value[k] = v
+			}
+		}
+		return value, true
+	})
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, id interface{}) (int, error) {
+	return s.submit(ctx, id, func(origin map[string]interface{}, keys map[string]interface{}) (map[string]interface{}, bool) {
+		return nil, origin != nil
+	})
+}
+
+func (s *MemoryStore) submit(ctx context.Context, id interface{}, build func(map[string]interface{}, map[string]interface{}) (map[string]interface{}, bool)) (int, error) {
+	key, keys, err := buildKeys(s.KeyBuilder, s.IdNames, id)
+	if err != nil {
+		return s.Status.Error, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	origin := s.records[fmt.Sprint(key)]
+	value, ok := build(normalizeJson(origin), keys)
+	if !ok {
+		return s.Status.NotFound, nil
+	}
+	by := ""
+	if s.GetUser != nil {
+		by = s.GetUser(ctx)
+	}
+	now := time.Now()
+	// the id of a composite key is kept as its fields, as the sql reader returns it
+	var diffId interface{} = key
+	if len(s.IdNames) > 1 {
+		diffId = keys
+	}
+	diff := DiffModel{Id: diffId, By: by, Timestamp: &now, EntityType: s.EntityType, Kind: GetKind("", origin, value)}
+	if origin != nil {
+		diff.Origin = normalizeJson(origin)
+	}
+	if value != nil {
+		diff.Value = normalizeJson(value)
+	}
+	s.staged[fmt.Sprint(key)] = diff
+	return s.Status.Success, nil
+}
+
+func (s *MemoryStore) Diff(ctx context.Context, id interface{}) (*DiffModel, error) {
+	key, _, err := buildKeys(s.KeyBuilder, s.IdNames, id)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	diff, ok := s.staged[fmt.Sprint(key)]
+	if !ok {
+		return nil, nil
+	}
+	result := copyDiff(diff)
+	return &result, nil
+}
+
+func (s *MemoryStore) Approve(ctx context.Context, id interface{}) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.approve(ctx, nil, id)
+}
+
+func (s *MemoryStore) Reject(ctx context.Context, id interface{}) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.reject(ctx, nil, id)
+}
+
+// Write appends the approved change to the history of the store, so that MemoryStore can be used as the HistoryWriter of other approvers; tx is ignored.
+func (s *MemoryStore) Write(ctx context.Context, tx *sql.Tx, tableName string, id interface{}, diff DiffModel, approvedBy string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.write(tableName, id, diff, approvedBy)
+	return nil
+}
+
+func (s *MemoryStore) Load(ctx context.Context, historyId string) (*DiffModel, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	i, err := strconv.Atoi(historyId)
+	if err != nil || i <= 0 || i > len(s.histories) {
+		return nil, nil
+	}
+	result := copyDiff(s.histories[i-1])
+	return &result, nil
+}
+
+// Histories returns the reviewed changes in the order they were written, the rejected ones only with KeepRejected; the history id of an entry is its position, starting at 1.
+func (s *MemoryStore) Histories() []DiffModel {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	results := make([]DiffModel, 0)
+	for _, diff := range s.histories {
+		results = append(results, copyDiff(diff))
+	}
+	return results
+}
+
+func (s *MemoryStore) write(tableName string, id interface{}, diff DiffModel, approvedBy string) {
+	now := time.Now()
+	entry := copyDiff(diff)
+	entry.Id = id
+	entry.EntityType = tableName
+	entry.ApprovedBy = approvedBy
+	entry.Timestamp = &now
+	s.histories = append(s.histories, entry)
+}
+
+func (s *MemoryStore) approve(ctx context.Context, tx *sql.Tx, id interface{}) (int, error) {
+	key, _, err := buildKeys(s.KeyBuilder, s.IdNames, id)
+	if err != nil {
+		return s.Status.Error, err
+	}
+	k := fmt.Sprint(key)
+	diff, ok := s.staged[k]
+	if !ok {
+		return s.Status.NotFound, nil
+	}
+	approvedBy := ""
+	if s.GetUser != nil {
+		approvedBy = s.GetUser(ctx)
+	}
+	if len(approvedBy) == 0 || (!s.AllowSelfApproval && approvedBy == diff.By) {
+		return s.Status.Forbidden, nil
+	}
+	origin, _ := diff.Origin.(map[string]interface{})
+	value, _ := diff.Value.(map[string]interface{})
+	current := s.records[k]
+	if IsStale(origin, current, s.Version) {
+		return s.Status.VersionError, &VersionConflict{Id: id, Origin: origin, Current: normalizeJson(current), Value: value}
+	}
+	switch GetKind(diff.Kind, origin, value) {
+	case KindDelete:
+		delete(s.records, k)
+	default:
+		record := normalizeJson(value)
+		if current != nil && len(s.Version) > 0 {
+			if v, ok := toFloat(current[s.Version]); ok {
+				record[s.Version] = int64(v) + 1
+			}
+		}
+		s.records[k] = record
+	}
+	delete(s.staged, k)
+	s.write(s.EntityType, id, review(ctx, diff, StateApproved), approvedBy)
+	return s.Status.Success, nil
+}
+
+func (s *MemoryStore) reject(ctx context.Context, tx *sql.Tx, id interface{}) (int, error) {
+	key, _, err := buildKeys(s.KeyBuilder, s.IdNames, id)
+	if err != nil {
+		return s.Status.Error, err
+	}
+	k := fmt.Sprint(key)
+	diff, ok := s.staged[k]
+	if !ok {
+		return s.Status.NotFound, nil
+	}
+	delete(s.staged, k)
+	if s.KeepRejected {
+		rejectedBy := ""
+		if s.GetUser != nil {
+			rejectedBy = s.GetUser(ctx)
+		}
+		s.write(s.EntityType, id, review(ctx, diff, StateRejected), rejectedBy)
+	}
+	return s.Status.Success, nil
+}
+
+// review sets the state of a reviewed change and the reason given by its reviewer.
+func review(ctx context.Context, diff DiffModel, state string) DiffModel {
+	diff.State = state
+	if r := GetReview(ctx); r != nil {
+		diff.Reason = r.Reason
+	}
+	return diff
+}
+
+func (r MemoryDiffListReader) Diff(ctx context.Context, ids interface{}) (*[]DiffModel, error) {
+	list, err := toList(ids)
+	if err != nil {
+		return nil, err
+	}
+	s := r.Store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	results := make([]DiffModel, 0)
+	for _, id := range list {
+		key, _, err := buildKeys(s.KeyBuilder, s.IdNames, id)
+		if err != nil {
+			return nil, err
+		}
+		if diff, ok := s.staged[fmt.Sprint(key)]; ok {
+			results = append(results, copyDiff(diff))
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return fmt.Sprint(results[i].Id) < fmt.Sprint(results[j].Id)
+	})
+	return &results, nil
+}
+
+func (r MemoryApprListService) Approve(ctx context.Context, ids interface{}) (int, error) {
+	results, err := r.ApproveList(ctx, ids)
+	return r.getStatus(results, err)
+}
+
+func (r MemoryApprListService) Reject(ctx context.Context, ids interface{}) (int, error) {
+	results, err := r.RejectList(ctx, ids)
+	return r.getStatus(results, err)
+}
+
+func (r MemoryApprListService) ApproveList(ctx context.Context, ids interface{}) ([]ApprResult, error) {
+	return r.execute(ctx, ids, r.Store.approve)
+}
+
+func (r MemoryApprListService) RejectList(ctx context.Context, ids interface{}) ([]ApprResult, error) {
+	return r.execute(ctx, ids, r.Store.reject)
+}
+
+// execute applies exec to all ids at once; on an unexpected error the store is restored, like a rolled back transaction.
+func (r MemoryApprListService) execute(ctx context.Context, ids interface{}, exec func(context.Context, *sql.Tx, interface{}) (int, error)) ([]ApprResult, error) {
+	list, err := toList(ids)
+	if err != nil {
+		return nil, err
+	}
+	s := r.Store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	records := make(map[string]map[string]interface{}, len(s.records))
+	for k, v := range s.records {
+		records[k] = v
+	}
+	staged := make(map[string]DiffModel, len(s.staged))
+	for k, v := range s.staged {
+		staged[k] = v
+	}
+	histories := len(s.histories)
+	results := make([]ApprResult, 0)
+	for _, id := range list {
+		if _, _, er1 := buildKeys(s.KeyBuilder, s.IdNames, id); er1 != nil {
+			results = append(results, ApprResult{Id: id, Status: s.Status.Error, Error: er1.Error()})
+			continue
+		}
+		status, er2 := exec(ctx, nil, id)
+		if er2 != nil {
+			var conflict *VersionConflict
+			if errors.As(er2, &conflict) {
+				results = append(results, ApprResult{Id: id, Status: status, Error: er2.Error(), Conflict: conflict})
+				continue
+			}
+			s.records, s.staged, s.histories = records, staged, s.histories[:histories]
+			return nil, er2
+		}
+		results = append(results, ApprResult{Id: id, Status: status})
+	}
+	return results, nil
+}
+
+func (r MemoryApprListService) getStatus(results []ApprResult, err error) (int, error) {
+	if err != nil {
+		return r.Store.Status.Error, err
+	}
+	for _, result := range results {
+		if result.Status != r.Store.Status.Success {
+			return result.Status, nil
+		}
+	}
+	return r.Store.Status.Success, nil
+}
+
+func copyDiff(diff DiffModel) DiffModel {
+	result := diff
+	if origin, ok := diff.Origin.(map[string]interface{}); ok && origin != nil {
+		result.Origin = normalizeJson(origin)
+	}
+	if value, ok := diff.Value.(map[string]interface{}); ok && value != nil {
+		result.Value = normalizeJson(value)
+	}
+	result.Changes = BuildChanges(result.Origin, result.Value)
+	return result
+}
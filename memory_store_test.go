@@ -0,0 +1,61 @@
+package diff_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	d "github.com/core-go/diff"
+	"github.com/core-go/diff/difftest"
+)
+
+func TestMemoryStoreServiceSuite(t *testing.T) {
+	difftest.RunServiceSuite(t, func(t *testing.T, idNames []string) *difftest.Backend {
+		user := ""
+		store := d.NewMemoryStore("users", idNames, nil, d.NewDefaultKeyBuilder(), func(context.Context) string { return user }, "version")
+		return &difftest.Backend{
+			Diff:     store,
+			DiffList: d.NewMemoryDiffListReader(store),
+			Appr:     store,
+			ApprList: d.NewMemoryApprListService(store),
+			Submit:   store,
+			Status:   store.Status,
+			SetUser:  func(u string) { user = u },
+			Seed: func(ctx context.Context, id interface{}, record map[string]interface{}) error {
+				return store.Put(id, record)
+			},
+			Get: func(ctx context.Context, id interface{}) (map[string]interface{}, error) {
+				return store.Get(id)
+			},
+			History: func(ctx context.Context) ([]d.DiffModel, error) {
+				return store.Histories(), nil
+			},
+		}
+	})
+}
+
+func TestMemoryStoreKeepsRejectedChangesAndCompositeIds(t *testing.T) {
+	user := "maker"
+	store := d.NewMemoryStore("items", []string{"id", "code"}, nil, d.NewDefaultKeyBuilder(), func(context.Context) string { return user })
+	store.KeepRejected = true
+	ctx := context.Background()
+	id := map[string]interface{}{"id": "a", "code": "x"}
+	if err := store.Put(id, map[string]interface{}{"id": "a", "code": "x", "name": "A"}); err != nil {
+		t.Fatal(err)
+	}
+	if status, err := store.Submit(ctx, id, map[string]interface{}{"name": "B"}); err != nil || status != store.Status.Success {
+		t.Fatalf("submit = %d %v", status, err)
+	}
+	diff, err := store.Diff(ctx, id)
+	if err != nil || diff == nil || !reflect.DeepEqual(diff.Id, id) {
+		t.Fatalf("diff = %+v %v, want the id %v", diff, err, id)
+	}
+	user = "checker"
+	if status, err := store.Reject(d.WithReview(ctx, &d.Review{Reason: "no"}), id); err != nil || status != store.Status.Success {
+		t.Fatalf("reject = %d %v", status, err)
+	}
+	histories := store.Histories()
+	if len(histories) != 1 || histories[0].State != d.StateRejected || histories[0].ApprovedBy != "checker" || histories[0].Reason != "no" {
+		t.Errorf("histories = %+v, want the rejected change", histories)
+	}
+}
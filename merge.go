@@ -0,0 +1,62 @@
+package diff
+
+import "sort"
+
+// Merge rebases the changes made from origin to value onto current.
+// Fields changed on one side only are merged; fields changed differently on both sides keep the proposed value and are reported as conflicts.
+func Merge(origin interface{}, current interface{}, value interface{}) (interface{}, []string) {
+	conflicts := make([]string, 0)
+	merged, _ := mergeValues("", toJsonValue(origin), true, toJsonValue(current), true, toJsonValue(value), true, &conflicts)
+	return merged, conflicts
+}
+
+func mergeValues(path string, o interface{}, hasO bool, c interface{}, hasC bool, v interface{}, hasV bool, conflicts *[]string) (interface{}, bool) {
+	if sameValue(o, hasO, v, hasV) {
+		return c, hasC
+	}
+	if sameValue(o, hasO, c, hasC) || sameValue(c, hasC, v, hasV) {
+		return v, hasV
+	}
+	om, ok1 := o.(map[string]interface{})
+	cm, ok2 := c.(map[string]interface{})
+	vm, ok3 := v.(map[string]interface{})
+	if ok1 && ok2 && ok3 {
+		keys := make(map[string]bool)
+		for _, m := range []map[string]interface{}{om, cm, vm} {
+			for k := range m {
+				keys[k] = true
+			}
+		}
+		names := make([]string, 0)
+		for k := range keys {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+		result := make(map[string]interface{})
+		for _, k := range names {
+			p := k
+			if len(path) > 0 {
+				p = path + "." + k
+			}
+			ov, hasOv := om[k]
+			cv, hasCv := cm[k]
+			vv, hasVv := vm[k]
+			if mv, ok := mergeValues(p, ov, hasOv, cv, hasCv, vv, hasVv, conflicts); ok {
+				result[k] = mv
+			}
+		}
+		return result, true
+	}
+	*conflicts = append(*conflicts, path)
+	return v, hasV
+}
+
+func sameValue(a interface{}, hasA bool, b interface{}, hasB bool) bool {
+	if hasA != hasB {
+		return false
+	}
+	if !hasA {
+		return true
+	}
+	return len(BuildChanges(map[string]interface{}{"": a}, map[string]interface{}{"": b})) == 0
+}
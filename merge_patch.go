@@ -0,0 +1,23 @@
+package diff
+
+// MergePatch applies an RFC 7386 JSON Merge Patch to target; target is not modified.
+func MergePatch(target interface{}, patch interface{}) interface{} {
+	p, ok := toJsonValue(patch).(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	result := make(map[string]interface{})
+	if t, ok := toJsonValue(target).(map[string]interface{}); ok {
+		for k, v := range t {
+			result[k] = v
+		}
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(result, k)
+		} else {
+			result[k] = MergePatch(result[k], v)
+		}
+	}
+	return result
+}
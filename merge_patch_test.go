@@ -0,0 +1,46 @@
+package diff_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	d "github.com/core-go/diff"
+)
+
+func TestMergePatch(t *testing.T) {
+	// the examples of appendix A of RFC 7386
+	tests := []struct {
+		target string
+		patch  string
+		result string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, test := range tests {
+		target := decode(t, test.target)
+		b, err := json.Marshal(d.MergePatch(target, decode(t, test.patch)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != test.result {
+			t.Errorf("MergePatch(%s, %s) = %s, want %s", test.target, test.patch, b, test.result)
+		}
+		if b, _ := json.Marshal(target); string(b) != test.target {
+			t.Errorf("MergePatch(%s, %s) modified the target to %s", test.target, test.patch, b)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package diff_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	d "github.com/core-go/diff"
+)
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name      string
+		origin    string
+		current   string
+		value     string
+		merged    string
+		conflicts []string
+	}{
+		{"changes on both sides", `{"a":1,"b":1}`, `{"a":2,"b":1}`, `{"a":1,"b":3}`, `{"a":2,"b":3}`, []string{}},
+		{"the same change on both sides", `{"a":1}`, `{"a":2}`, `{"a":2}`, `{"a":2}`, []string{}},
+		{"different changes of a field", `{"a":1,"b":1}`, `{"a":2,"b":1}`, `{"a":3,"b":1}`, `{"a":3,"b":1}`, []string{"a"}},
+		{"a field removed by the change", `{"a":1,"b":1}`, `{"a":2,"b":1}`, `{"a":1}`, `{"a":2}`, []string{}},
+		{"a field removed from the current record and changed", `{"a":1,"b":1}`, `{"a":1}`, `{"a":1,"b":2}`, `{"a":1,"b":2}`, []string{"b"}},
+		{"a field added on both sides", `{}`, `{"a":1}`, `{"a":2}`, `{"a":2}`, []string{"a"}},
+		{"nested changes", `{"x":{"a":1,"b":1}}`, `{"x":{"a":2,"b":1}}`, `{"x":{"a":1,"b":2}}`, `{"x":{"a":2,"b":2}}`, []string{}},
+		{"nested conflicts", `{"x":{"a":1,"b":1}}`, `{"x":{"a":2,"b":2}}`, `{"x":{"a":3,"b":2}}`, `{"x":{"a":3,"b":2}}`, []string{"x.a"}},
+	}
+	for _, test := range tests {
+		merged, conflicts := d.Merge(decode(t, test.origin), decode(t, test.current), decode(t, test.value))
+		if b, _ := json.Marshal(merged); string(b) != test.merged {
+			t.Errorf("%s: merged = %s, want %s", test.name, b, test.merged)
+		}
+		if !reflect.DeepEqual(conflicts, test.conflicts) {
+			t.Errorf("%s: conflicts = %v, want %v", test.name, conflicts, test.conflicts)
+		}
+	}
+}
+
+func decode(t *testing.T, s string) interface{} {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
@@ -0,0 +1,546 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	d "github.com/core-go/diff"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+var errRollback = errors.New("rollback")
+
+type HistoryWriter interface {
+	Write(ctx context.Context, collection string, id interface{}, diff d.DiffModel, approvedBy string) error
+}
+
+type MongoDiffReader struct {
+	Collection *mongo.Collection
+	Table      string
+	EntityType string
+	IdNames    []string
+	Config     d.DiffConfig
+	KeyBuilder d.KeyBuilder
+}
+
+type MongoDiffListReader struct {
+	Collection *mongo.Collection
+	Table      string
+	EntityType string
+	IdNames    []string
+	Config     d.DiffConfig
+	KeyBuilder d.KeyBuilder
+}
+
+type MongoApprover struct {
+	Database          *mongo.Database
+	Collection        *mongo.Collection
+	Table             string
+	EntityType        string
+	IdNames           []string
+	Config            d.DiffConfig
+	Status            d.StatusConfig
+	KeyBuilder        d.KeyBuilder
+	History           HistoryWriter
+	GetUser           func(context.Context) string
+	Fields            map[string]string
+	AllowSelfApproval bool
+}
+
+type MongoApprListService struct {
+	Approver *MongoApprover
+}
+
+// NewMongoDiffReader reads staged changes of the table collection from the staging collection, where entityType is the field holding the table name.
+func NewMongoDiffReader(db *mongo.Database, table string, entity string, entityType string, idNames []string, config d.DiffConfig, keyBuilder d.KeyBuilder) *MongoDiffReader {
+	return &MongoDiffReader{Collection: db.Collection(entity), Table: table, EntityType: entityType, IdNames: idNames, Config: getDefaultConfig(config), KeyBuilder: keyBuilder}
+}
+
+func NewMongoDiffListReader(db *mongo.Database, table string, entity string, entityType string, idNames []string, config d.DiffConfig, keyBuilder d.KeyBuilder) *MongoDiffListReader {
+	return &MongoDiffListReader{Collection: db.Collection(entity), Table: table, EntityType: entityType, IdNames: idNames, Config: getDefaultConfig(config), KeyBuilder: keyBuilder}
+}
+
+func NewMongoApprover(db *mongo.Database, table string, entity string, entityType string, modelType reflect.Type, idNames []string, config d.DiffConfig, status *d.StatusConfig, keyBuilder d.KeyBuilder, history HistoryWriter, getUser func(context.Context) string) *MongoApprover {
+	fields := getBsonFields(modelType)
+	return &MongoApprover{Database: db, Collection: db.Collection(entity), Table: table, EntityType: entityType, IdNames: idNames, Config: getDefaultConfig(config), Status: d.InitializeStatus(status), KeyBuilder: keyBuilder, History: history, GetUser: getUser, Fields: fields}
+}
+
+func NewMongoApprListService(db *mongo.Database, table string, entity string, entityType string, modelType reflect.Type, idNames []string, config d.DiffConfig, status *d.StatusConfig, keyBuilder d.KeyBuilder, history HistoryWriter, getUser func(context.Context) string) *MongoApprListService {
+	approver := NewMongoApprover(db, table, entity, entityType, modelType, idNames, config, status, keyBuilder, history, getUser)
+	return &MongoApprListService{Approver: approver}
+}
+
+func (r MongoApprover) GetStatus() d.StatusConfig {
+	return r.Status
+}
+
+func (s MongoApprListService) GetStatus() d.StatusConfig {
+	return s.Approver.Status
+}
+
+func getDefaultConfig(config d.DiffConfig) d.DiffConfig {
+	if config.Id == "" {
+		config.Id = "_id"
+	}
+	if config.Origin == "" {
+		config.Origin = "origin"
+	}
+	if config.Value == "" {
+		config.Value = "value"
+	}
+	return config
+}
+
+func (r MongoDiffReader) Diff(ctx context.Context, id interface{}) (*d.DiffModel, error) {
+	key, keys, err := buildKeys(r.KeyBuilder, r.IdNames, id)
+	if err != nil {
+		return nil, err
+	}
+	diff, err := getStagedDiff(ctx, r.Collection, r.Config, r.EntityType, r.Table, key)
+	if diff != nil && len(r.IdNames) > 1 {
+		// a composite id is returned as its fields, as the sql reader does
+		diff.Id = keys
+	}
+	return diff, err
+}
+
+func (r MongoDiffListReader) Diff(ctx context.Context, ids interface{}) (*[]d.DiffModel, error) {
+	list, err := toList(ids)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]interface{}, 0)
+	for _, id := range list {
+		key, _, err := buildKeys(r.KeyBuilder, r.IdNames, id)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	filter := bson.M{r.Config.Id: bson.M{"$in": keys}}
+	if len(r.EntityType) > 0 {
+		filter[r.EntityType] = r.Table
+	}
+	cursor, err := r.Collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	var docs []bson.M
+	err = cursor.All(ctx, &docs)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]d.DiffModel, 0)
+	for _, doc := range docs {
+		results = append(results, *toDiffModel(doc, r.Config, r.EntityType))
+	}
+	return &results, nil
+}
+
+func (r MongoApprover) Approve(ctx context.Context, id interface{}) (int, error) {
+	return r.transact(ctx, func(sc context.Context) (int, error) {
+		return r.approve(sc, id)
+	})
+}
+
+func (r MongoApprover) Reject(ctx context.Context, id interface{}) (int, error) {
+	return r.transact(ctx, func(sc context.Context) (int, error) {
+		return r.reject(sc, id)
+	})
+}
+
+// transact runs exec in a multi-document transaction, which is aborted when exec fails or does not succeed.
+func (r MongoApprover) transact(ctx context.Context, exec func(context.Context) (int, error)) (int, error) {
+	session, err := r.Database.Client().StartSession()
+	if err != nil {
+		return r.Status.Error, err
+	}
+	defer session.EndSession(ctx)
+	status := r.Status.Error
+	var er1 error
+	_, err = session.WithTransaction(ctx, func(sc context.Context) (interface{}, error) {
+		status, er1 = exec(sc)
+		if er1 != nil {
+			return nil, er1
+		}
+		if status != r.Status.Success {
+			return nil, errRollback
+		}
+		return nil, nil
+	})
+	if er1 != nil {
+		return status, er1
+	}
+	if err != nil && !errors.Is(err, errRollback) {
+		return r.Status.Error, err
+	}
+	return status, nil
+}
+
+func (r MongoApprover) approve(ctx context.Context, id interface{}) (int, error) {
+	key, keys, err := buildKeys(r.KeyBuilder, r.IdNames, id)
+	if err != nil {
+		return r.Status.Error, err
+	}
+	diff, err := getStagedDiff(ctx, r.Collection, r.Config, r.EntityType, r.Table, key)
+	if err != nil {
+		return r.Status.Error, err
+	}
+	if diff == nil {
+		return r.Status.NotFound, nil
+	}
+	approvedBy := ""
+	if r.GetUser != nil {
+		approvedBy = r.GetUser(ctx)
+	}
+	if len(approvedBy) == 0 || (!r.AllowSelfApproval && approvedBy == diff.By) {
+		return r.Status.Forbidden, nil
+	}
+	origin, _ := diff.Origin.(map[string]interface{})
+	value, _ := diff.Value.(map[string]interface{})
+	table := r.Database.Collection(r.Table)
+	filter := r.buildFilter(keys)
+	current, err := r.getCurrent(ctx, table, filter)
+	if err != nil {
+		return r.Status.Error, err
+	}
+	versionName := getJsonName(r.Fields, r.Config.Version)
+	if d.IsStale(origin, current, versionName) {
+		return r.Status.VersionError, &d.VersionConflict{Id: id, Origin: origin, Current: current, Value: value}
+	}
+	kind := d.GetKind(diff.Kind, origin, value)
+	if kind != d.KindDelete && value == nil {
+		return r.Status.Error, fmt.Errorf("the %s change of %v has no value", kind, id)
+	}
+	switch kind {
+	case d.KindDelete:
+		_, err = table.DeleteOne(ctx, filter)
+	default:
+		if current != nil && len(versionName) > 0 {
+			if v, ok := toInt64(current[versionName]); ok {
+				value[versionName] = v + 1
+			}
+		}
+		_, err = table.ReplaceOne(ctx, filter, r.toDocument(value), options.Replace().SetUpsert(true))
+	}
+	if err != nil {
+		return r.Status.Error, err
+	}
+	_, err = r.Collection.DeleteOne(ctx, buildStagedFilter(r.Config, r.EntityType, r.Table, key))
+	if err != nil {
+		return r.Status.Error, err
+	}
+	if r.History != nil {
+		err = r.History.Write(ctx, r.Table, id, *diff, approvedBy)
+		if err != nil {
+			return r.Status.Error, err
+		}
+	}
+	return r.Status.Success, nil
+}
+
+func (r MongoApprover) reject(ctx context.Context, id interface{}) (int, error) {
+	key, _, err := buildKeys(r.KeyBuilder, r.IdNames, id)
+	if err != nil {
+		return r.Status.Error, err
+	}
+	result, err := r.Collection.DeleteOne(ctx, buildStagedFilter(r.Config, r.EntityType, r.Table, key))
+	if err != nil {
+		return r.Status.Error, err
+	}
+	if result.DeletedCount <= 0 {
+		return r.Status.NotFound, nil
+	}
+	return r.Status.Success, nil
+}
+
+func (r MongoApprover) buildFilter(keys map[string]interface{}) bson.M {
+	filter := bson.M{}
+	for _, name := range r.IdNames {
+		filter[getField(r.Fields, name)] = keys[name]
+	}
+	return filter
+}
+
+func (r MongoApprover) getCurrent(ctx context.Context, table *mongo.Collection, filter bson.M) (map[string]interface{}, error) {
+	var doc bson.M
+	err := table.FindOne(ctx, filter).Decode(&doc)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	current := make(map[string]interface{})
+	for field, v := range doc {
+		current[getJsonName(r.Fields, field)] = toJson(v)
+	}
+	return current, nil
+}
+
+func (r MongoApprover) toDocument(value map[string]interface{}) bson.M {
+	doc := bson.M{}
+	for name, v := range value {
+		doc[getField(r.Fields, name)] = v
+	}
+	return doc
+}
+
+func (s MongoApprListService) Approve(ctx context.Context, ids interface{}) (int, error) {
+	results, err := s.ApproveList(ctx, ids)
+	return s.getStatus(results, err)
+}
+
+func (s MongoApprListService) Reject(ctx context.Context, ids interface{}) (int, error) {
+	results, err := s.RejectList(ctx, ids)
+	return s.getStatus(results, err)
+}
+
+func (s MongoApprListService) ApproveList(ctx context.Context, ids interface{}) ([]d.ApprResult, error) {
+	return s.execute(ctx, ids, s.Approver.approve)
+}
+
+func (s MongoApprListService) RejectList(ctx context.Context, ids interface{}) ([]d.ApprResult, error) {
+	return s.execute(ctx, ids, s.Approver.reject)
+}
+
+func (s MongoApprListService) execute(ctx context.Context, ids interface{}, exec func(context.Context, interface{}) (int, error)) ([]d.ApprResult, error) {
+	list, err := toList(ids)
+	if err != nil {
+		return nil, err
+	}
+	r := s.Approver
+	var results []d.ApprResult
+	_, err = r.transact(ctx, func(sc context.Context) (int, error) {
+		results = make([]d.ApprResult, 0)
+		for _, id := range list {
+			if _, _, er1 := buildKeys(r.KeyBuilder, r.IdNames, id); er1 != nil {
+				results = append(results, d.ApprResult{Id: id, Status: r.Status.Error, Error: er1.Error()})
+				continue
+			}
+			status, er2 := exec(sc, id)
+			if er2 != nil {
+				var conflict *d.VersionConflict
+				if errors.As(er2, &conflict) {
+					results = append(results, d.ApprResult{Id: id, Status: status, Error: er2.Error(), Conflict: conflict})
+					continue
+				}
+				return r.Status.Error, er2
+			}
+			results = append(results, d.ApprResult{Id: id, Status: status})
+		}
+		return r.Status.Success, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (s MongoApprListService) getStatus(results []d.ApprResult, err error) (int, error) {
+	if err != nil {
+		return s.Approver.Status.Error, err
+	}
+	for _, result := range results {
+		if result.Status != s.Approver.Status.Success {
+			return result.Status, nil
+		}
+	}
+	return s.Approver.Status.Success, nil
+}
+
+func getStagedDiff(ctx context.Context, collection *mongo.Collection, config d.DiffConfig, entityType string, table string, key interface{}) (*d.DiffModel, error) {
+	var doc bson.M
+	err := collection.FindOne(ctx, buildStagedFilter(config, entityType, table, key)).Decode(&doc)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return toDiffModel(doc, config, entityType), nil
+}
+
+func buildStagedFilter(config d.DiffConfig, entityType string, table string, key interface{}) bson.M {
+	filter := bson.M{config.Id: key}
+	if len(entityType) > 0 {
+		filter[entityType] = table
+	}
+	return filter
+}
+
+func toDiffModel(doc bson.M, config d.DiffConfig, entityType string) *d.DiffModel {
+	result := &d.DiffModel{Id: doc[config.Id]}
+	if v, ok := toJson(doc[config.Origin]).(map[string]interface{}); ok {
+		result.Origin = v
+	}
+	if v, ok := toJson(doc[config.Value]).(map[string]interface{}); ok {
+		result.Value = v
+	}
+	result.Changes = d.BuildChanges(result.Origin, result.Value)
+	by := config.ChangedBy
+	if len(by) == 0 {
+		by = config.ApprovedBy
+	}
+	if s, ok := doc[by].(string); ok && len(by) > 0 {
+		result.By = s
+	}
+	if s, ok := doc[entityType].(string); ok && len(entityType) > 0 {
+		result.EntityType = s
+	}
+	if len(config.Timestamp) > 0 {
+		if t, ok := doc[config.Timestamp].(bson.DateTime); ok {
+			v := t.Time()
+			result.Timestamp = &v
+		}
+	}
+	kind := ""
+	if s, ok := doc[config.Kind].(string); ok && len(config.Kind) > 0 {
+		kind = s
+	}
+	result.Kind = d.GetKind(kind, result.Origin, result.Value)
+	return result
+}
+
+// toJson converts decoded bson documents and arrays to plain maps and slices, so that they compare and marshal as json.
+func toJson(v interface{}) interface{} {
+	switch x := v.(type) {
+	case bson.D:
+		m := make(map[string]interface{})
+		for _, e := range x {
+			m[e.Key] = toJson(e.Value)
+		}
+		return m
+	case bson.M:
+		m := make(map[string]interface{})
+		for k, e := range x {
+			m[k] = toJson(e)
+		}
+		return m
+	case map[string]interface{}:
+		m := make(map[string]interface{})
+		for k, e := range x {
+			m[k] = toJson(e)
+		}
+		return m
+	case bson.A:
+		return toJson([]interface{}(x))
+	case []interface{}:
+		a := make([]interface{}, len(x))
+		for i, e := range x {
+			a[i] = toJson(e)
+		}
+		return a
+	case bson.DateTime:
+		return x.Time().UTC().Format(time.RFC3339Nano)
+	case bson.ObjectID:
+		return x.Hex()
+	default:
+		return v
+	}
+}
+
+func toInt64(v interface{}) (int64, bool) {
+	switch x := v.(type) {
+	case int32:
+		return int64(x), true
+	case int64:
+		return x, true
+	case int:
+		return int64(x), true
+	case float64:
+		return int64(x), true
+	default:
+		return 0, false
+	}
+}
+
+// getBsonFields maps json names of modelType to bson field names.
+func getBsonFields(modelType reflect.Type) map[string]string {
+	fields := make(map[string]string)
+	if modelType == nil {
+		return fields
+	}
+	for i := 0; i < modelType.NumField(); i++ {
+		field := modelType.Field(i)
+		jsonName := strings.Split(field.Tag.Get("json"), ",")[0]
+		if jsonName == "" || jsonName == "-" {
+			continue
+		}
+		bsonName := strings.Split(field.Tag.Get("bson"), ",")[0]
+		if bsonName == "" {
+			bsonName = strings.ToLower(field.Name)
+		}
+		if bsonName != "-" {
+			fields[jsonName] = bsonName
+		}
+	}
+	return fields
+}
+
+func getField(fields map[string]string, jsonName string) string {
+	if field, ok := fields[jsonName]; ok {
+		return field
+	}
+	return jsonName
+}
+
+func getJsonName(fields map[string]string, field string) string {
+	for jsonName, f := range fields {
+		if f == field {
+			return jsonName
+		}
+	}
+	return field
+}
+
+func toList(ids interface{}) ([]interface{}, error) {
+	if ids == nil {
+		return nil, errors.New("failed keys nil")
+	}
+	v := reflect.Indirect(reflect.ValueOf(ids))
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, errors.New("ids must be a slice")
+	}
+	list := make([]interface{}, 0)
+	for i := 0; i < v.Len(); i++ {
+		list = append(list, v.Index(i).Interface())
+	}
+	return list, nil
+}
+
+func buildKeys(keyBuilder d.KeyBuilder, idNames []string, id interface{}) (interface{}, map[string]interface{}, error) {
+	if keyMap, ok := id.(map[string]interface{}); ok {
+		key := keyBuilder.BuildKeyFromMap(keyMap, idNames)
+		if key == "" {
+			return nil, nil, errors.New("failed to build key")
+		}
+		return key, keyMap, nil
+	}
+	if v := reflect.Indirect(reflect.ValueOf(id)); v.Kind() == reflect.Struct {
+		key := keyBuilder.BuildKey(id)
+		if key == "" {
+			return nil, nil, errors.New("failed to build key")
+		}
+		keyMap := make(map[string]interface{})
+		for i := 0; i < v.NumField(); i++ {
+			jsonName := strings.Split(v.Type().Field(i).Tag.Get("json"), ",")[0]
+			for _, name := range idNames {
+				if name == jsonName {
+					keyMap[jsonName] = v.Field(i).Interface()
+				}
+			}
+		}
+		return key, keyMap, nil
+	}
+	if len(idNames) != 1 {
+		return nil, nil, errors.New("invalid id: composite key must be a map")
+	}
+	return id, map[string]interface{}{idNames[0]: id}, nil
+}
@@ -0,0 +1,160 @@
+package mongo_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+
+	d "github.com/core-go/diff"
+	"github.com/core-go/diff/difftest"
+	m "github.com/core-go/diff/mongo"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type User struct {
+	Id      string `json:"id" bson:"id"`
+	Code    string `json:"code,omitempty" bson:"code,omitempty"`
+	Name    string `json:"name" bson:"name"`
+	Version int    `json:"version" bson:"version"`
+}
+
+// stager stages changes the way an application does, since the package has no submitter.
+type stager struct {
+	db         *mongo.Database
+	idNames    []string
+	keyBuilder d.KeyBuilder
+	user       *string
+}
+
+func (s stager) Stage(ctx context.Context, id interface{}, value map[string]interface{}) error {
+	origin, err := find(ctx, s.db.Collection("users"), filterOf(id, s.idNames))
+	if err != nil {
+		return err
+	}
+	key := fmt.Sprint(id)
+	if keys, ok := id.(map[string]interface{}); ok {
+		key = s.keyBuilder.BuildKeyFromMap(keys, s.idNames)
+	}
+	doc := bson.M{"_id": key, "entitytype": "users", "origin": origin, "value": value, "changedby": *s.user}
+	_, err = s.db.Collection("pending").ReplaceOne(ctx, bson.M{"_id": key}, doc, options.Replace().SetUpsert(true))
+	return err
+}
+
+func filterOf(id interface{}, idNames []string) bson.M {
+	if keys, ok := id.(map[string]interface{}); ok {
+		return bson.M(keys)
+	}
+	return bson.M{idNames[0]: id}
+}
+
+func find(ctx context.Context, collection *mongo.Collection, filter bson.M) (map[string]interface{}, error) {
+	var doc map[string]interface{}
+	err := collection.FindOne(ctx, filter, options.FindOne().SetProjection(bson.M{"_id": 0})).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	return doc, err
+}
+
+// connect connects to the mongod of MONGO_URI, which must run as a replica set, since the approvers use transactions, e.g. MONGO_URI=mongodb://localhost:27017/?replicaSet=rs0
+func connect(t *testing.T) *mongo.Client {
+	t.Helper()
+	uri := os.Getenv("MONGO_URI")
+	if len(uri) == 0 {
+		t.Skip("MONGO_URI is not set")
+	}
+	client, err := mongo.Connect(options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Disconnect(context.Background()) })
+	return client
+}
+
+func TestServiceSuite(t *testing.T) {
+	client := connect(t)
+	databases := 0
+	difftest.RunServiceSuite(t, func(t *testing.T, idNames []string) *difftest.Backend {
+		databases++
+		db := client.Database(fmt.Sprintf("difftest%d", databases))
+		ctx := context.Background()
+		if err := db.Drop(ctx); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Drop(context.Background()) })
+		for _, name := range []string{"users", "pending", "history"} {
+			if err := db.CreateCollection(ctx, name); err != nil {
+				t.Fatal(err)
+			}
+		}
+		user := ""
+		getUser := func(context.Context) string { return user }
+		config := d.DiffConfig{ChangedBy: "changedby", Version: "version"}
+		keyBuilder := d.NewDefaultKeyBuilder()
+		modelType := reflect.TypeOf(User{})
+		history := m.NewMongoHistoryWriter(db, "history", "entitytype", idNames, d.DiffConfig{ChangedBy: "changedby", ApprovedBy: "approvedby"}, keyBuilder, nil)
+		users := db.Collection("users")
+		return &difftest.Backend{
+			Diff:     m.NewMongoDiffReader(db, "users", "pending", "entitytype", idNames, config, keyBuilder),
+			DiffList: m.NewMongoDiffListReader(db, "users", "pending", "entitytype", idNames, config, keyBuilder),
+			Appr:     m.NewMongoApprover(db, "users", "pending", "entitytype", modelType, idNames, config, nil, keyBuilder, history, getUser),
+			ApprList: m.NewMongoApprListService(db, "users", "pending", "entitytype", modelType, idNames, config, nil, keyBuilder, history, getUser),
+			Stage:    stager{db: db, idNames: idNames, keyBuilder: keyBuilder, user: &user}.Stage,
+			Status:   d.InitializeStatus(nil),
+			SetUser:  func(u string) { user = u },
+			Seed: func(ctx context.Context, id interface{}, record map[string]interface{}) error {
+				_, err := users.ReplaceOne(ctx, filterOf(id, idNames), bson.M(record), options.Replace().SetUpsert(true))
+				return err
+			},
+			Get: func(ctx context.Context, id interface{}) (map[string]interface{}, error) {
+				return find(ctx, users, filterOf(id, idNames))
+			},
+			History: func(ctx context.Context) ([]d.DiffModel, error) {
+				cursor, err := db.Collection("history").Find(ctx, bson.M{})
+				if err != nil {
+					return nil, err
+				}
+				var docs []struct {
+					Value map[string]interface{} `bson:"value"`
+					By    string                 `bson:"changedby"`
+				}
+				err = cursor.All(ctx, &docs)
+				if err != nil {
+					return nil, err
+				}
+				histories := make([]d.DiffModel, 0)
+				for _, doc := range docs {
+					histories = append(histories, d.DiffModel{Value: doc.Value, By: doc.By})
+				}
+				return histories, nil
+			},
+		}
+	})
+}
+
+func TestApproveAnUpdateWithoutValueIsAnError(t *testing.T) {
+	db := connect(t).Database("difftestnull")
+	ctx := context.Background()
+	if err := db.Drop(ctx); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Drop(context.Background()) })
+	for _, name := range []string{"users", "pending"} {
+		if err := db.CreateCollection(ctx, name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := db.Collection("pending").InsertOne(ctx, bson.M{"_id": "u1", "entitytype": "users", "origin": bson.M{"id": "u1", "name": "A"}, "value": nil, "kind": d.KindUpdate, "changedby": "maker"}); err != nil {
+		t.Fatal(err)
+	}
+	getUser := func(context.Context) string { return "checker" }
+	approver := m.NewMongoApprover(db, "users", "pending", "entitytype", reflect.TypeOf(User{}), []string{"id"}, d.DiffConfig{ChangedBy: "changedby", Kind: "kind"}, nil, d.NewDefaultKeyBuilder(), nil, getUser)
+	if status, err := approver.Approve(ctx, "u1"); err == nil || status != approver.Status.Error {
+		t.Errorf("approve = %d %v, want Error", status, err)
+	}
+}
@@ -0,0 +1,77 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	d "github.com/core-go/diff"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+type MongoHistoryWriter struct {
+	Database   *mongo.Database
+	Collection string
+	EntityType string
+	IdNames    []string
+	Config     d.DiffConfig
+	KeyBuilder d.KeyBuilder
+	Generate   func() (string, error)
+}
+
+// NewMongoHistoryWriter writes approved changes to the history collection; when generate is nil, the history id is a new ObjectID.
+func NewMongoHistoryWriter(db *mongo.Database, collection string, entityType string, idNames []string, config d.DiffConfig, keyBuilder d.KeyBuilder, generate func() (string, error)) *MongoHistoryWriter {
+	if config.HistoryId == "" {
+		config.HistoryId = "_id"
+	}
+	if config.Id == "" {
+		config.Id = "id"
+	}
+	if config.Origin == "" {
+		config.Origin = "origin"
+	}
+	if config.Value == "" {
+		config.Value = "value"
+	}
+	return &MongoHistoryWriter{Database: db, Collection: collection, EntityType: entityType, IdNames: idNames, Config: config, KeyBuilder: keyBuilder, Generate: generate}
+}
+
+// Write inserts the history document; it joins the transaction carried by ctx.
+func (w MongoHistoryWriter) Write(ctx context.Context, collection string, id interface{}, diff d.DiffModel, approvedBy string) error {
+	entityID := ""
+	if len(w.IdNames) == 1 {
+		entityID = fmt.Sprint(id)
+	} else if v, ok := id.(map[string]interface{}); ok {
+		entityID = w.KeyBuilder.BuildKeyFromMap(v, w.IdNames)
+	} else {
+		entityID = w.KeyBuilder.BuildKey(id)
+	}
+	doc := bson.M{w.Config.Id: entityID, w.Config.Origin: diff.Origin, w.Config.Value: diff.Value}
+	if w.Generate != nil {
+		historyID, err := w.Generate()
+		if err != nil {
+			return err
+		}
+		doc[w.Config.HistoryId] = historyID
+	} else {
+		doc[w.Config.HistoryId] = bson.NewObjectID().Hex()
+	}
+	if len(w.EntityType) > 0 {
+		doc[w.EntityType] = collection
+	}
+	if len(w.Config.ChangedBy) > 0 {
+		doc[w.Config.ChangedBy] = diff.By
+	}
+	if len(w.Config.ApprovedBy) > 0 {
+		doc[w.Config.ApprovedBy] = approvedBy
+	}
+	if len(w.Config.Timestamp) > 0 {
+		doc[w.Config.Timestamp] = time.Now()
+	}
+	if len(w.Config.Kind) > 0 {
+		doc[w.Config.Kind] = diff.Kind
+	}
+	_, err := w.Database.Collection(w.Collection).InsertOne(ctx, doc)
+	return err
+}
@@ -0,0 +1,246 @@
+package diff
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+const ChangePending = "ChangePending"
+
+type Notifier interface {
+	Notify(ctx context.Context, event Event) error
+}
+
+// SubmitNotifier sends a ChangePending event after a change is staged by SubmitService.
+type SubmitNotifier struct {
+	SubmitService SubmitService
+	DiffService   DiffService
+	Notifier      Notifier
+	EntityType    string
+	Status        StatusConfig
+	Async         bool
+	Log           func(context.Context, string)
+}
+
+// ApprNotifier sends a ChangeApproved or ChangeRejected event after ApprService approves or rejects a change.
+// Events are sent before Approve and Reject return, or in the background when Async is set.
+type ApprNotifier struct {
+	ApprService ApprService
+	DiffService DiffService
+	Notifier    Notifier
+	EntityType  string
+	Status      StatusConfig
+	GetUser     func(context.Context) string
+	Async       bool
+	Log         func(context.Context, string)
+}
+
+// ApprListNotifier sends an event for each change ApprListService approves or rejects; when ApprListService does not implement ApprListResultService, the events are sent only if all the changes succeed.
+type ApprListNotifier struct {
+	ApprListService ApprListService
+	DiffService     DiffService
+	Notifier        Notifier
+	EntityType      string
+	Status          StatusConfig
+	GetUser         func(context.Context) string
+	Async           bool
+	Log             func(context.Context, string)
+}
+
+func NewSubmitNotifier(submitService SubmitService, diffService DiffService, notifier Notifier, entityType string, status *StatusConfig, logError func(context.Context, string)) *SubmitNotifier {
+	return &SubmitNotifier{SubmitService: submitService, DiffService: diffService, Notifier: notifier, EntityType: entityType, Status: InitializeStatus(status), Log: logError}
+}
+
+func NewApprNotifier(apprService ApprService, diffService DiffService, notifier Notifier, entityType string, status *StatusConfig, getUser func(context.Context) string, logError func(context.Context, string)) *ApprNotifier {
+	return &ApprNotifier{ApprService: apprService, DiffService: diffService, Notifier: notifier, EntityType: entityType, Status: InitializeStatus(status), GetUser: getUser, Log: logError}
+}
+
+func NewApprListNotifier(apprListService ApprListService, diffService DiffService, notifier Notifier, entityType string, status *StatusConfig, getUser func(context.Context) string, logError func(context.Context, string)) *ApprListNotifier {
+	return &ApprListNotifier{ApprListService: apprListService, DiffService: diffService, Notifier: notifier, EntityType: entityType, Status: InitializeStatus(status), GetUser: getUser, Log: logError}
+}
+
+func (s SubmitNotifier) GetStatus() StatusConfig {
+	return s.Status
+}
+
+func (s ApprNotifier) GetStatus() StatusConfig {
+	return s.Status
+}
+
+func (s ApprListNotifier) GetStatus() StatusConfig {
+	return s.Status
+}
+
+func (s SubmitNotifier) Submit(ctx context.Context, id interface{}, patch map[string]interface{}) (int, error) {
+	status, err := s.SubmitService.Submit(ctx, id, patch)
+	s.notifyPending(ctx, id, status, err)
+	return status, err
+}
+
+func (s SubmitNotifier) Delete(ctx context.Context, id interface{}) (int, error) {
+	status, err := s.SubmitService.Delete(ctx, id)
+	s.notifyPending(ctx, id, status, err)
+	return status, err
+}
+
+func (s SubmitNotifier) notifyPending(ctx context.Context, id interface{}, status int, err error) {
+	if err != nil || status != s.Status.Success {
+		return
+	}
+	diff, err := s.DiffService.Diff(ctx, id)
+	if err != nil || diff == nil {
+		report(ctx, s.Log, err)
+		return
+	}
+	event := BuildEvent(ChangePending, s.EntityType, id, *diff)
+	notify(ctx, s.Notifier, event, s.Async, s.Log)
+}
+
+func (s ApprNotifier) Approve(ctx context.Context, id interface{}) (int, error) {
+	return s.execute(ctx, id, ChangeApproved, s.ApprService.Approve)
+}
+
+func (s ApprNotifier) Reject(ctx context.Context, id interface{}) (int, error) {
+	return s.execute(ctx, id, ChangeRejected, s.ApprService.Reject)
+}
+
+func (s ApprNotifier) execute(ctx context.Context, id interface{}, eventType string, exec func(context.Context, interface{}) (int, error)) (int, error) {
+	diff, er1 := s.DiffService.Diff(ctx, id)
+	status, err := exec(ctx, id)
+	if err != nil || status != s.Status.Success {
+		return status, err
+	}
+	if er1 != nil || diff == nil {
+		report(ctx, s.Log, er1)
+		return status, err
+	}
+	notify(ctx, s.Notifier, buildReviewedEvent(ctx, eventType, s.EntityType, id, *diff, s.GetUser), s.Async, s.Log)
+	return status, err
+}
+
+func (s ApprListNotifier) Approve(ctx context.Context, ids interface{}) (int, error) {
+	return s.execute(ctx, ids, ChangeApproved, s.ApprListService.Approve)
+}
+
+func (s ApprListNotifier) Reject(ctx context.Context, ids interface{}) (int, error) {
+	return s.execute(ctx, ids, ChangeRejected, s.ApprListService.Reject)
+}
+
+func (s ApprListNotifier) ApproveList(ctx context.Context, ids interface{}) ([]ApprResult, error) {
+	service, ok := s.ApprListService.(ApprListResultService)
+	if !ok {
+		return nil, errors.New("ApproveList is not supported")
+	}
+	return s.executeList(ctx, ids, ChangeApproved, service.ApproveList)
+}
+
+func (s ApprListNotifier) RejectList(ctx context.Context, ids interface{}) ([]ApprResult, error) {
+	service, ok := s.ApprListService.(ApprListResultService)
+	if !ok {
+		return nil, errors.New("RejectList is not supported")
+	}
+	return s.executeList(ctx, ids, ChangeRejected, service.RejectList)
+}
+
+func (s ApprListNotifier) execute(ctx context.Context, ids interface{}, eventType string, exec func(context.Context, interface{}) (int, error)) (int, error) {
+	list, diffs := s.getDiffs(ctx, ids)
+	status, err := exec(ctx, ids)
+	if err != nil || status != s.Status.Success {
+		return status, err
+	}
+	for i, id := range list {
+		if diffs[i] != nil {
+			notify(ctx, s.Notifier, buildReviewedEvent(ctx, eventType, s.EntityType, id, *diffs[i], s.GetUser), s.Async, s.Log)
+		}
+	}
+	return status, err
+}
+
+func (s ApprListNotifier) executeList(ctx context.Context, ids interface{}, eventType string, exec func(context.Context, interface{}) ([]ApprResult, error)) ([]ApprResult, error) {
+	list, diffs := s.getDiffs(ctx, ids)
+	results, err := exec(ctx, ids)
+	if err != nil {
+		return results, err
+	}
+	for i, result := range results {
+		if result.Status == s.Status.Success && i < len(list) && diffs[i] != nil {
+			notify(ctx, s.Notifier, buildReviewedEvent(ctx, eventType, s.EntityType, list[i], *diffs[i], s.GetUser), s.Async, s.Log)
+		}
+	}
+	return results, err
+}
+
+// getDiffs loads the changes before they are approved or rejected; a change which cannot be loaded is reported and sent no event.
+func (s ApprListNotifier) getDiffs(ctx context.Context, ids interface{}) ([]interface{}, []*DiffModel) {
+	list, err := toList(ids)
+	if err != nil {
+		return nil, nil
+	}
+	diffs := make([]*DiffModel, len(list))
+	for i, id := range list {
+		diff, err := s.DiffService.Diff(ctx, id)
+		if err != nil {
+			report(ctx, s.Log, err)
+			continue
+		}
+		diffs[i] = diff
+	}
+	return list, diffs
+}
+
+func buildReviewedEvent(ctx context.Context, eventType string, entityType string, id interface{}, diff DiffModel, getUser func(context.Context) string) Event {
+	event := BuildEvent(eventType, entityType, id, diff)
+	if getUser != nil {
+		event.ApprovedBy = getUser(ctx)
+	}
+	if review := GetReview(ctx); review != nil {
+		event.Reason = review.Reason
+	}
+	return event
+}
+
+func BuildEvent(eventType string, entityType string, id interface{}, diff DiffModel) Event {
+	if len(diff.EntityType) > 0 {
+		entityType = diff.EntityType
+	}
+	event := Event{Type: eventType, EntityType: entityType, EntityId: id, Origin: toJsonValue(diff.Origin), Value: toJsonValue(diff.Value), By: diff.By, ApprovedBy: diff.ApprovedBy, Reason: diff.Reason}
+	event.Kind = GetKind(diff.Kind, event.Origin, event.Value)
+	return event
+}
+
+// notify sends the event in the background when async is set, with ctx detached from the request, which may be done before the event is sent.
+// Background sends are neither bounded nor waited for, so the events still being sent when the process exits are lost; an outbox keeps them.
+func notify(ctx context.Context, notifier Notifier, event Event, async bool, logError func(context.Context, string)) {
+	if async {
+		ctx = detachedContext{ctx}
+		go func() {
+			report(ctx, logError, notifier.Notify(ctx, event))
+		}()
+		return
+	}
+	report(ctx, logError, notifier.Notify(ctx, event))
+}
+
+// detachedContext keeps the values of a context, such as the request id for logging, but is never done.
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
+
+func report(ctx context.Context, logError func(context.Context, string), err error) {
+	if err != nil && logError != nil {
+		logError(ctx, err.Error())
+	}
+}
@@ -0,0 +1,82 @@
+package diff_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	d "github.com/core-go/diff"
+)
+
+type eventChannel chan d.Event
+
+func (c eventChannel) Notify(ctx context.Context, event d.Event) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	c <- event
+	return nil
+}
+
+func TestApprListNotifierSendsAnEventPerApprovedChange(t *testing.T) {
+	user := "maker"
+	store := d.NewMemoryStore("users", []string{"id"}, nil, d.NewDefaultKeyBuilder(), func(context.Context) string { return user })
+	for _, id := range []string{"u1", "u2"} {
+		if err := store.Put(id, map[string]interface{}{"id": id, "name": "a"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	if _, err := store.Submit(ctx, "u1", map[string]interface{}{"name": "b"}); err != nil {
+		t.Fatal(err)
+	}
+	events := make(eventChannel, 2)
+	user = "checker"
+	service := d.NewApprListNotifier(d.NewMemoryApprListService(store), store, events, "users", nil, func(context.Context) string { return user }, nil)
+	service.Async = true
+	results, err := service.ApproveList(ctx, []interface{}{"u1", "u2"})
+	// the events are sent in the background, after the request is done
+	cancel()
+	if err != nil || len(results) != 2 || results[0].Status != service.Status.Success || results[1].Status != service.Status.NotFound {
+		t.Fatalf("approve list = %v %v", results, err)
+	}
+	select {
+	case event := <-events:
+		if event.Type != d.ChangeApproved || event.EntityId != "u1" || event.ApprovedBy != "checker" {
+			t.Errorf("event = %+v", event)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no event was sent")
+	}
+	select {
+	case event := <-events:
+		t.Errorf("unexpected event %+v for a change which was not approved", event)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestApprNotifierSendsTheEventBeforeReturning(t *testing.T) {
+	user := "maker"
+	store := d.NewMemoryStore("users", []string{"id"}, nil, d.NewDefaultKeyBuilder(), func(context.Context) string { return user })
+	if err := store.Put("u1", map[string]interface{}{"id": "u1", "name": "a"}); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if _, err := store.Submit(ctx, "u1", map[string]interface{}{"name": "b"}); err != nil {
+		t.Fatal(err)
+	}
+	events := make(eventChannel, 1)
+	user = "checker"
+	service := d.NewApprNotifier(store, store, events, "users", nil, func(context.Context) string { return user }, nil)
+	if status, err := service.Reject(ctx, "u1"); err != nil || status != service.Status.Success {
+		t.Fatalf("reject = %d %v", status, err)
+	}
+	select {
+	case event := <-events:
+		if event.Type != d.ChangeRejected || event.EntityId != "u1" {
+			t.Errorf("event = %+v", event)
+		}
+	default:
+		t.Error("the event was not sent before Reject returned")
+	}
+}
@@ -0,0 +1,42 @@
+package diff
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const (
+	ChangeApproved = "ChangeApproved"
+	ChangeRejected = "ChangeRejected"
+)
+
+type Event struct {
+	Id         string      `yaml:"id" mapstructure:"id" json:"id,omitempty" gorm:"column:id;primary_key" bson:"_id,omitempty" dynamodbav:"id,omitempty" firestore:"id,omitempty"`
+	Type       string      `yaml:"type" mapstructure:"type" json:"type,omitempty" gorm:"column:type" bson:"type,omitempty" dynamodbav:"type,omitempty" firestore:"type,omitempty"`
+	EntityType string      `yaml:"entity_type" mapstructure:"entity_type" json:"entityType,omitempty" gorm:"column:entity_type" bson:"entityType,omitempty" dynamodbav:"entityType,omitempty" firestore:"entityType,omitempty"`
+	EntityId   interface{} `yaml:"entity_id" mapstructure:"entity_id" json:"entityId,omitempty" gorm:"column:entity_id" bson:"entityId,omitempty" dynamodbav:"entityId,omitempty" firestore:"entityId,omitempty"`
+	Origin     interface{} `yaml:"origin" mapstructure:"origin" json:"origin,omitempty" gorm:"column:origin" bson:"origin,omitempty" dynamodbav:"origin,omitempty" firestore:"origin,omitempty"`
+	Value      interface{} `yaml:"value" mapstructure:"value" json:"value,omitempty" gorm:"column:value" bson:"value,omitempty" dynamodbav:"value,omitempty" firestore:"value,omitempty"`
+	Kind       string      `yaml:"kind" mapstructure:"kind" json:"kind,omitempty" gorm:"column:kind" bson:"kind,omitempty" dynamodbav:"kind,omitempty" firestore:"kind,omitempty"`
+	By         string      `yaml:"by" mapstructure:"by" json:"by,omitempty" gorm:"column:by" bson:"by,omitempty" dynamodbav:"by,omitempty" firestore:"by,omitempty"`
+	ApprovedBy string      `yaml:"approved_by" mapstructure:"approved_by" json:"approvedBy,omitempty" gorm:"column:approved_by" bson:"approvedBy,omitempty" dynamodbav:"approvedBy,omitempty" firestore:"approvedBy,omitempty"`
+	Reason     string      `yaml:"reason" mapstructure:"reason" json:"reason,omitempty" gorm:"column:reason" bson:"reason,omitempty" dynamodbav:"reason,omitempty" firestore:"reason,omitempty"`
+	Timestamp  *time.Time  `yaml:"timestamp" mapstructure:"timestamp" json:"timestamp,omitempty" gorm:"column:timestamp" bson:"timestamp,omitempty" dynamodbav:"timestamp,omitempty" firestore:"timestamp,omitempty"`
+}
+
+// EventWriter writes an event inside the transaction of the approval, so that the event is stored if and only if the change is applied.
+type EventWriter interface {
+	Write(ctx context.Context, tx *sql.Tx, event Event) error
+}
+
+type EventSink interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// EventSinkFunc adapts a function to EventSink.
+type EventSinkFunc func(ctx context.Context, event Event) error
+
+func (f EventSinkFunc) Publish(ctx context.Context, event Event) error {
+	return f(ctx, event)
+}
@@ -0,0 +1,242 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	FormatText     = "text"
+	FormatMarkdown = "markdown"
+	FormatHtml     = "html"
+)
+
+type Renderer struct {
+	Labels map[string]string
+	Fields []string
+}
+
+type renderRow struct {
+	Label   string
+	Origin  string
+	Value   string
+	Changed bool
+}
+
+// NewRenderer takes the labels from the "label" tag of modelType, or from the tag given in options; fields without the tag are labelled by their name.
+func NewRenderer(modelType reflect.Type, options ...string) *Renderer {
+	tag := "label"
+	if len(options) > 0 && len(options[0]) > 0 {
+		tag = options[0]
+	}
+	labels, fields := GetLabels(modelType, tag)
+	return &Renderer{Labels: labels, Fields: fields}
+}
+
+func GetLabels(modelType reflect.Type, tag string) (map[string]string, []string) {
+	labels := make(map[string]string)
+	fields := make([]string, 0)
+	if modelType == nil {
+		return labels, fields
+	}
+	if modelType.Kind() == reflect.Ptr {
+		modelType = modelType.Elem()
+	}
+	if modelType.Kind() != reflect.Struct {
+		return labels, fields
+	}
+	for i := 0; i < modelType.NumField(); i++ {
+		field := modelType.Field(i)
+		jsonName := strings.Split(field.Tag.Get("json"), ",")[0]
+		if jsonName == "-" {
+			continue
+		}
+		if jsonName == "" {
+			jsonName = field.Name
+		}
+		label := strings.Split(field.Tag.Get(tag), ",")[0]
+		if label == "" {
+			label = field.Name
+		}
+		labels[jsonName] = label
+		fields = append(fields, jsonName)
+	}
+	return labels, fields
+}
+
+// GetRenderFormat returns the format of the query parameter "format" if it is text, markdown or html, otherwise an empty string.
+func GetRenderFormat(r *http.Request) string {
+	if r.URL == nil {
+		return ""
+	}
+	switch format := r.URL.Query().Get("format"); format {
+	case FormatText, FormatMarkdown, FormatHtml:
+		return format
+	default:
+		return ""
+	}
+}
+
+func GetRenderContentType(format string) string {
+	switch format {
+	case FormatMarkdown:
+		return "text/markdown; charset=utf-8"
+	case FormatHtml:
+		return "text/html; charset=utf-8"
+	default:
+		return "text/plain; charset=utf-8"
+	}
+}
+
+func (r Renderer) Render(diff DiffModel, format string) string {
+	switch format {
+	case FormatMarkdown:
+		return r.Markdown(diff)
+	case FormatHtml:
+		return r.Html(diff)
+	default:
+		return r.Text(diff)
+	}
+}
+
+// Text renders the change as a unified diff, one line per field.
+func (r Renderer) Text(diff DiffModel) string {
+	var b strings.Builder
+	b.WriteString(r.title(diff) + "\n")
+	b.WriteString("--- origin\n+++ value\n")
+	for _, row := range r.rows(diff) {
+		if !row.Changed {
+			b.WriteString("  " + row.Label + ": " + row.Value + "\n")
+			continue
+		}
+		if len(row.Origin) > 0 || diff.Origin != nil {
+			b.WriteString("- " + row.Label + ": " + row.Origin + "\n")
+		}
+		if len(row.Value) > 0 || diff.Value != nil {
+			b.WriteString("+ " + row.Label + ": " + row.Value + "\n")
+		}
+	}
+	return b.String()
+}
+
+func (r Renderer) Markdown(diff DiffModel) string {
+	var b strings.Builder
+	b.WriteString("**" + escapeMarkdown(r.title(diff)) + "**\n\n")
+	b.WriteString("| Field | Origin | Value |\n| --- | --- | --- |\n")
+	for _, row := range r.rows(diff) {
+		label, origin, value := escapeMarkdown(row.Label), escapeMarkdown(row.Origin), escapeMarkdown(row.Value)
+		if row.Changed {
+			label = "**" + label + "**"
+			if len(origin) > 0 {
+				origin = "~~" + origin + "~~"
+			}
+			if len(value) > 0 {
+				value = "**" + value + "**"
+			}
+		}
+		b.WriteString("| " + label + " | " + origin + " | " + value + " |\n")
+	}
+	return b.String()
+}
+
+// Html renders a side-by-side table; changed rows are highlighted with inline styles, so that the table keeps its look in email clients.
+func (r Renderer) Html(diff DiffModel) string {
+	var b strings.Builder
+	b.WriteString(`<table class="diff" style="border-collapse:collapse">` + "\n")
+	b.WriteString("<caption>" + html.EscapeString(r.title(diff)) + "</caption>\n")
+	b.WriteString("<thead><tr><th>Field</th><th>Origin</th><th>Value</th></tr></thead>\n<tbody>\n")
+	for _, row := range r.rows(diff) {
+		label, origin, value := html.EscapeString(row.Label), html.EscapeString(row.Origin), html.EscapeString(row.Value)
+		if row.Changed {
+			b.WriteString(`<tr class="changed" style="background:#fff8c5">`)
+			b.WriteString("<td><b>" + label + "</b></td>")
+			b.WriteString(`<td style="background:#ffebe9"><del>` + origin + "</del></td>")
+			b.WriteString(`<td style="background:#dafbe1"><ins>` + value + "</ins></td></tr>\n")
+		} else {
+			b.WriteString("<tr><td>" + label + "</td><td>" + origin + "</td><td>" + value + "</td></tr>\n")
+		}
+	}
+	b.WriteString("</tbody>\n</table>\n")
+	return b.String()
+}
+
+func (r Renderer) title(diff DiffModel) string {
+	s := strings.TrimSpace(diff.EntityType + " " + formatValue(diff.Id))
+	if kind := GetKind(diff.Kind, diff.Origin, diff.Value); len(kind) > 0 {
+		s = s + " (" + kind + ")"
+	}
+	if len(diff.By) > 0 {
+		s = s + " by " + diff.By
+	}
+	return strings.TrimSpace(s)
+}
+
+func (r Renderer) rows(diff DiffModel) []renderRow {
+	origin, _ := toJsonValue(diff.Origin).(map[string]interface{})
+	value, _ := toJsonValue(diff.Value).(map[string]interface{})
+	names := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, name := range r.Fields {
+		_, ok1 := origin[name]
+		_, ok2 := value[name]
+		if ok1 || ok2 {
+			names = append(names, name)
+			seen[name] = true
+		}
+	}
+	var others []string
+	for _, m := range []map[string]interface{}{origin, value} {
+		for name := range m {
+			if !seen[name] {
+				others = append(others, name)
+				seen[name] = true
+			}
+		}
+	}
+	sort.Strings(others)
+	names = append(names, others...)
+	rows := make([]renderRow, 0)
+	for _, name := range names {
+		o, ok1 := origin[name]
+		v, ok2 := value[name]
+		changed := ok1 != ok2 || len(BuildChanges(map[string]interface{}{name: o}, map[string]interface{}{name: v})) > 0
+		label := name
+		if l, ok := r.Labels[name]; ok {
+			label = l
+		}
+		rows = append(rows, renderRow{Label: label, Origin: formatValue(o), Value: formatValue(v), Changed: changed})
+	}
+	return rows
+}
+
+func formatValue(v interface{}) string {
+	switch x := toJsonValue(v).(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	case json.Number:
+		return x.String()
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	case map[string]interface{}, []interface{}:
+		b, err := json.Marshal(x)
+		if err != nil {
+			return fmt.Sprint(x)
+		}
+		return string(b)
+	default:
+		return fmt.Sprint(x)
+	}
+}
+
+func escapeMarkdown(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.ReplaceAll(s, "\n", "<br>")
+}
@@ -0,0 +1,109 @@
+package diff_test
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	d "github.com/core-go/diff"
+)
+
+type Person struct {
+	Id     string `json:"id" label:"ID" title:"Code"`
+	Name   string `json:"name,omitempty" label:"Full name"`
+	Age    int    `json:"age"`
+	Secret string `json:"-" label:"Secret"`
+}
+
+func TestGetLabels(t *testing.T) {
+	tests := []struct {
+		name   string
+		tag    string
+		labels map[string]string
+	}{
+		{"label tag", "label", map[string]string{"id": "ID", "name": "Full name", "age": "Age"}},
+		{"other tag", "title", map[string]string{"id": "Code", "name": "Name", "age": "Age"}},
+	}
+	for _, test := range tests {
+		labels, fields := d.GetLabels(reflect.TypeOf(&Person{}), test.tag)
+		if !reflect.DeepEqual(labels, test.labels) || !reflect.DeepEqual(fields, []string{"id", "name", "age"}) {
+			t.Errorf("%s: labels = %v, fields = %v, want %v in the order of the struct", test.name, labels, fields, test.labels)
+		}
+	}
+	if labels, fields := d.GetLabels(reflect.TypeOf(""), "label"); len(labels) != 0 || len(fields) != 0 {
+		t.Errorf("labels of a string = %v %v, want none", labels, fields)
+	}
+}
+
+func TestGetRenderFormat(t *testing.T) {
+	tests := []struct {
+		url    string
+		format string
+	}{
+		{"/users/1/diff", ""},
+		{"/users/1/diff?format=text", d.FormatText},
+		{"/users/1/diff?format=markdown", d.FormatMarkdown},
+		{"/users/1/diff?format=html", d.FormatHtml},
+		{"/users/1/diff?format=pdf", ""},
+	}
+	for _, test := range tests {
+		if format := d.GetRenderFormat(httptest.NewRequest("GET", test.url, nil)); format != test.format {
+			t.Errorf("%s: format = %q, want %q", test.url, format, test.format)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	update := d.DiffModel{
+		Id:         "p1",
+		EntityType: "person",
+		By:         "maker",
+		Origin:     map[string]interface{}{"id": "p1", "name": "A|B", "age": 30.0},
+		Value:      map[string]interface{}{"id": "p1", "name": "C", "age": 31.0, "note": "<x>"},
+	}
+	create := d.DiffModel{Id: "p2", Value: &map[string]interface{}{"name": "D", "tags": []interface{}{"a", 1.5}}}
+	tests := []struct {
+		name   string
+		diff   d.DiffModel
+		format string
+		want   string
+	}{
+		{"text of an update", update, d.FormatText, "person p1 (update) by maker\n" +
+			"--- origin\n+++ value\n" +
+			"  ID: p1\n" +
+			"- Full name: A|B\n+ Full name: C\n" +
+			"- Age: 30\n+ Age: 31\n" +
+			"- note: \n+ note: <x>\n"},
+		{"text by default", create, "", "p2 (create)\n" +
+			"--- origin\n+++ value\n" +
+			"+ Full name: D\n" +
+			"+ tags: [\"a\",1.5]\n"},
+		{"markdown of an update", update, d.FormatMarkdown, "**person p1 (update) by maker**\n\n" +
+			"| Field | Origin | Value |\n| --- | --- | --- |\n" +
+			"| ID | p1 | p1 |\n" +
+			"| **Full name** | ~~A\\|B~~ | **C** |\n" +
+			"| **Age** | ~~30~~ | **31** |\n" +
+			"| **note** |  | **<x>** |\n"},
+		{"html of a create", create, d.FormatHtml, "<table class=\"diff\" style=\"border-collapse:collapse\">\n" +
+			"<caption>p2 (create)</caption>\n" +
+			"<thead><tr><th>Field</th><th>Origin</th><th>Value</th></tr></thead>\n<tbody>\n" +
+			"<tr class=\"changed\" style=\"background:#fff8c5\"><td><b>Full name</b></td><td style=\"background:#ffebe9\"><del></del></td><td style=\"background:#dafbe1\"><ins>D</ins></td></tr>\n" +
+			"<tr class=\"changed\" style=\"background:#fff8c5\"><td><b>tags</b></td><td style=\"background:#ffebe9\"><del></del></td><td style=\"background:#dafbe1\"><ins>[&#34;a&#34;,1.5]</ins></td></tr>\n" +
+			"</tbody>\n</table>\n"},
+	}
+	renderer := d.NewRenderer(reflect.TypeOf(Person{}))
+	for _, test := range tests {
+		if s := renderer.Render(test.diff, test.format); s != test.want {
+			t.Errorf("%s:\n%s\nwant\n%s", test.name, s, test.want)
+		}
+	}
+}
+
+func TestRenderHtmlEscapesValues(t *testing.T) {
+	diff := d.DiffModel{Id: "p1", Origin: map[string]interface{}{"name": "A"}, Value: map[string]interface{}{"name": "<script>"}}
+	want := `<td style="background:#dafbe1"><ins>&lt;script&gt;</ins></td>`
+	if s := d.NewRenderer(reflect.TypeOf(Person{})).Html(diff); !strings.Contains(s, want) {
+		t.Errorf("html = %s, want the value escaped", s)
+	}
+}
@@ -0,0 +1,42 @@
+package diff
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+const ChangesRequested = "ChangesRequested"
+
+// Revision is a previous submission of a staged change; Changes compares it with the submission which replaced it.
+type Revision struct {
+	Revision  int           `yaml:"revision" mapstructure:"revision" json:"revision,omitempty" gorm:"column:revision" bson:"revision,omitempty" dynamodbav:"revision,omitempty" firestore:"revision,omitempty"`
+	Value     interface{}   `yaml:"value" mapstructure:"value" json:"value,omitempty" gorm:"column:value" bson:"value,omitempty" dynamodbav:"value,omitempty" firestore:"value,omitempty"`
+	By        string        `yaml:"by" mapstructure:"by" json:"by,omitempty" gorm:"column:by" bson:"by,omitempty" dynamodbav:"by,omitempty" firestore:"by,omitempty"`
+	Timestamp *time.Time    `yaml:"timestamp" mapstructure:"timestamp" json:"timestamp,omitempty" gorm:"column:timestamp" bson:"timestamp,omitempty" dynamodbav:"timestamp,omitempty" firestore:"timestamp,omitempty"`
+	Changes   []FieldChange `yaml:"changes" mapstructure:"changes" json:"changes,omitempty" gorm:"-" bson:"changes,omitempty" dynamodbav:"changes,omitempty" firestore:"changes,omitempty"`
+}
+
+// ChangeRequestService sends a staged change back to its author, who resubmits it with SubmitService.
+type ChangeRequestService interface {
+	RequestChanges(ctx context.Context, id interface{}) (int, error)
+}
+
+func decodeRevisions(s string, value interface{}) ([]Revision, error) {
+	var revisions []Revision
+	if len(s) == 0 {
+		return revisions, nil
+	}
+	err := json.Unmarshal([]byte(s), &revisions)
+	if err != nil {
+		return nil, err
+	}
+	for i := range revisions {
+		next := value
+		if i+1 < len(revisions) {
+			next = revisions[i+1].Value
+		}
+		revisions[i].Changes = BuildChanges(revisions[i].Value, next)
+	}
+	return revisions, nil
+}
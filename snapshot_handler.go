@@ -0,0 +1,57 @@
+package diff
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"time"
+)
+
+type SnapshotHandler struct {
+	SnapshotService SnapshotService
+	Keys            []string
+	ModelType       reflect.Type
+	Error           func(context.Context, string)
+	Indexes         map[string]int
+	Offset          int
+	Log             func(ctx context.Context, resource string, action string, success bool, desc string) error
+	Resource        string
+	Action          string
+}
+
+func NewSnapshotHandler(snapshotService SnapshotService, modelType reflect.Type, logError func(context.Context, string), writeLog func(context.Context, string, string, bool, string) error, options ...int) *SnapshotHandler {
+	return NewSnapshotHandlerWithKeys(snapshotService, nil, modelType, logError, writeLog, options...)
+}
+func NewSnapshotHandlerWithKeys(snapshotService SnapshotService, keys []string, modelType reflect.Type, logError func(context.Context, string), writeLog func(context.Context, string, string, bool, string) error, options ...int) *SnapshotHandler {
+	offset := 1
+	if len(options) > 0 && options[0] >= 0 {
+		offset = options[0]
+	}
+	if keys == nil || len(keys) == 0 {
+		keys = GetJsonPrimaryKeys(modelType)
+	}
+	indexes := GetIndexes(modelType)
+	resource := BuildResourceName(modelType.Name())
+	return &SnapshotHandler{Log: writeLog, SnapshotService: snapshotService, ModelType: modelType, Keys: keys, Indexes: indexes, Offset: offset, Error: logError, Resource: resource, Action: "snapshot"}
+}
+
+func (c *SnapshotHandler) Snapshot(w http.ResponseWriter, r *http.Request) {
+	id, er1 := BuildId(r, c.ModelType, c.Keys, c.Indexes, c.Offset)
+	if er1 != nil {
+		http.Error(w, er1.Error(), http.StatusBadRequest)
+		return
+	}
+	at, er2 := time.Parse(time.RFC3339, r.URL.Query().Get("at"))
+	if er2 != nil {
+		http.Error(w, er2.Error(), http.StatusBadRequest)
+		return
+	}
+	result, er3 := c.SnapshotService.Snapshot(r.Context(), id, at)
+	if er3 != nil {
+		handleError(w, r, http.StatusInternalServerError, internalServerError, c.Error, c.Resource, c.Action, er3, c.Log)
+	} else if result == nil {
+		succeed(w, r, http.StatusNotFound, result, c.Log, c.Resource, c.Action)
+	} else {
+		succeed(w, r, http.StatusOK, result, c.Log, c.Resource, c.Action)
+	}
+}
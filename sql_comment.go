@@ -0,0 +1,149 @@
+package diff
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type CommentConfig struct {
+	Id         string `yaml:"id" mapstructure:"id" json:"id,omitempty" gorm:"column:id" bson:"_id,omitempty" dynamodbav:"id,omitempty" firestore:"id,omitempty"`
+	EntityType string `yaml:"entity_type" mapstructure:"entity_type" json:"entityType,omitempty" gorm:"column:entitytype" bson:"entityType,omitempty" dynamodbav:"entityType,omitempty" firestore:"entityType,omitempty"`
+	Author     string `yaml:"author" mapstructure:"author" json:"author,omitempty" gorm:"column:author" bson:"author,omitempty" dynamodbav:"author,omitempty" firestore:"author,omitempty"`
+	Text       string `yaml:"text" mapstructure:"text" json:"text,omitempty" gorm:"column:text" bson:"text,omitempty" dynamodbav:"text,omitempty" firestore:"text,omitempty"`
+	Action     string `yaml:"action" mapstructure:"action" json:"action,omitempty" gorm:"column:action" bson:"action,omitempty" dynamodbav:"action,omitempty" firestore:"action,omitempty"`
+	Timestamp  string `yaml:"timestamp" mapstructure:"timestamp" json:"timestamp,omitempty" gorm:"column:timestamp" bson:"timestamp,omitempty" dynamodbav:"timestamp,omitempty" firestore:"timestamp,omitempty"`
+}
+
+type executor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// SqlCommentStore keeps the comment thread of each staged change of Table; the thread is moved to history when the change is approved or rejected.
+type SqlCommentStore struct {
+	DB          *sql.DB
+	Table       string
+	Entity      string
+	IdNames     []string
+	Config      CommentConfig
+	Status      StatusConfig
+	KeyBuilder  KeyBuilder
+	DiffService DiffService
+	GetUser     func(context.Context) string
+	BuildParam  func(int) string
+}
+
+func NewSqlCommentStore(db *sql.DB, table string, entity string, idNames []string, config CommentConfig, status *StatusConfig, keyBuilder KeyBuilder, diffService DiffService, getUser func(context.Context) string, options ...func(int) string) *SqlCommentStore {
+	var buildParam func(int) string
+	if len(options) > 0 && options[0] != nil {
+		buildParam = options[0]
+	} else {
+		buildParam = getBuild(db)
+	}
+	if config.Id == "" {
+		config.Id = "id"
+	}
+	if config.EntityType == "" {
+		config.EntityType = "entitytype"
+	}
+	if config.Author == "" {
+		config.Author = "author"
+	}
+	if config.Text == "" {
+		config.Text = "text"
+	}
+	if config.Action == "" {
+		config.Action = "action"
+	}
+	if config.Timestamp == "" {
+		config.Timestamp = "timestamp"
+	}
+	return &SqlCommentStore{DB: db, Table: table, Entity: entity, IdNames: idNames, Config: config, Status: InitializeStatus(status), KeyBuilder: keyBuilder, DiffService: diffService, GetUser: getUser, BuildParam: buildParam}
+}
+
+func (s SqlCommentStore) GetStatus() StatusConfig {
+	return s.Status
+}
+
+// Comment adds a comment to the thread of a staged change; when DiffService is set, it returns NotFound if no change is staged.
+func (s SqlCommentStore) Comment(ctx context.Context, id interface{}, text string) (int, error) {
+	key, _, err := buildKeys(s.KeyBuilder, s.IdNames, id)
+	if err != nil {
+		return s.Status.Error, err
+	}
+	if s.DiffService != nil {
+		diff, err := s.DiffService.Diff(ctx, id)
+		if err != nil {
+			return s.Status.Error, err
+		}
+		if diff == nil {
+			return s.Status.NotFound, nil
+		}
+	}
+	author := ""
+	if s.GetUser != nil {
+		author = s.GetUser(ctx)
+	}
+	now := time.Now()
+	err = s.add(ctx, s.DB, key, Comment{Author: author, Text: text, Action: CommentActionComment, Timestamp: &now})
+	if err != nil {
+		return s.Status.Error, err
+	}
+	return s.Status.Success, nil
+}
+
+func (s SqlCommentStore) Load(ctx context.Context, id interface{}) ([]Comment, error) {
+	key, _, err := buildKeys(s.KeyBuilder, s.IdNames, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.load(ctx, s.DB, key)
+}
+
+func (s SqlCommentStore) load(ctx context.Context, db executor, key interface{}) ([]Comment, error) {
+	query := fmt.Sprintf("select %s, %s, %s, %s from %s where %s = %s and %s = %s order by %s", s.Config.Author, s.Config.Text, s.Config.Action, s.Config.Timestamp, s.Table,
+		s.Config.Id, s.BuildParam(1),
+		s.Config.EntityType, s.BuildParam(2), s.Config.Timestamp)
+	rows, err := db.QueryContext(ctx, query, fmt.Sprint(key), s.Entity)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	comments := make([]Comment, 0)
+	for rows.Next() {
+		var author, text, action sql.NullString
+		var timestamp sql.NullTime
+		if err := rows.Scan(&author, &text, &action, &timestamp); err != nil {
+			return nil, err
+		}
+		comment := Comment{Author: author.String, Text: text.String, Action: action.String}
+		if timestamp.Valid {
+			t := timestamp.Time
+			comment.Timestamp = &t
+		}
+		comments = append(comments, comment)
+	}
+	return comments, rows.Err()
+}
+
+func (s SqlCommentStore) add(ctx context.Context, db executor, key interface{}, comment Comment) error {
+	columns := []string{s.Config.Id, s.Config.EntityType, s.Config.Author, s.Config.Text, s.Config.Action, s.Config.Timestamp}
+	timestamp := time.Now()
+	if comment.Timestamp != nil {
+		timestamp = *comment.Timestamp
+	}
+	query := fmt.Sprintf("insert into %s(%s) values (%s)", s.Table, strings.Join(columns, ","), buildParameters(len(columns), s.BuildParam))
+	_, err := db.ExecContext(ctx, query, fmt.Sprint(key), s.Entity, comment.Author, comment.Text, comment.Action, timestamp)
+	return err
+}
+
+func (s SqlCommentStore) clear(ctx context.Context, db executor, key interface{}) error {
+	query := fmt.Sprintf("delete from %s where %s = %s and %s = %s", s.Table,
+		s.Config.Id, s.BuildParam(1),
+		s.Config.EntityType, s.BuildParam(2))
+	_, err := db.ExecContext(ctx, query, fmt.Sprint(key), s.Entity)
+	return err
+}
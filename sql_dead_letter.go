@@ -0,0 +1,79 @@
+package diff
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type DeadLetterConfig struct {
+	Id        string `yaml:"id" mapstructure:"id" json:"id,omitempty" gorm:"column:id" bson:"_id,omitempty" dynamodbav:"id,omitempty" firestore:"id,omitempty"`
+	Url       string `yaml:"url" mapstructure:"url" json:"url,omitempty" gorm:"column:url" bson:"url,omitempty" dynamodbav:"url,omitempty" firestore:"url,omitempty"`
+	Payload   string `yaml:"payload" mapstructure:"payload" json:"payload,omitempty" gorm:"column:payload" bson:"payload,omitempty" dynamodbav:"payload,omitempty" firestore:"payload,omitempty"`
+	Error     string `yaml:"error" mapstructure:"error" json:"error,omitempty" gorm:"column:error" bson:"error,omitempty" dynamodbav:"error,omitempty" firestore:"error,omitempty"`
+	Attempts  string `yaml:"attempts" mapstructure:"attempts" json:"attempts,omitempty" gorm:"column:attempts" bson:"attempts,omitempty" dynamodbav:"attempts,omitempty" firestore:"attempts,omitempty"`
+	Timestamp string `yaml:"timestamp" mapstructure:"timestamp" json:"timestamp,omitempty" gorm:"column:timestamp" bson:"timestamp,omitempty" dynamodbav:"timestamp,omitempty" firestore:"timestamp,omitempty"`
+}
+
+// SqlDeadLetter keeps the webhook deliveries which failed after all retries.
+type SqlDeadLetter struct {
+	DB         *sql.DB
+	Table      string
+	Config     DeadLetterConfig
+	BuildParam func(int) string
+	Generate   func() (string, error)
+}
+
+func NewSqlDeadLetter(db *sql.DB, table string, config DeadLetterConfig, generate func() (string, error), options ...func(int) string) *SqlDeadLetter {
+	var buildParam func(int) string
+	if len(options) > 0 && options[0] != nil {
+		buildParam = options[0]
+	} else {
+		buildParam = getBuild(db)
+	}
+	if config.Url == "" {
+		config.Url = "url"
+	}
+	if config.Payload == "" {
+		config.Payload = "payload"
+	}
+	if config.Error == "" {
+		config.Error = "error"
+	}
+	return &SqlDeadLetter{DB: db, Table: table, Config: config, BuildParam: buildParam, Generate: generate}
+}
+
+func (s SqlDeadLetter) Write(ctx context.Context, url string, event Event, attempts int, cause error) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	message := ""
+	if cause != nil {
+		message = cause.Error()
+	}
+	columns := []string{s.Config.Url, s.Config.Payload, s.Config.Error}
+	values := []interface{}{url, string(payload), message}
+	if len(s.Config.Id) > 0 && s.Generate != nil {
+		id, err := s.Generate()
+		if err != nil {
+			return err
+		}
+		columns = append(columns, s.Config.Id)
+		values = append(values, id)
+	}
+	if len(s.Config.Attempts) > 0 {
+		columns = append(columns, s.Config.Attempts)
+		values = append(values, attempts)
+	}
+	if len(s.Config.Timestamp) > 0 {
+		columns = append(columns, s.Config.Timestamp)
+		values = append(values, time.Now())
+	}
+	query := fmt.Sprintf("insert into %s(%s) values (%s)", s.Table, strings.Join(columns, ","), buildParameters(len(columns), s.BuildParam))
+	_, err = s.DB.ExecContext(ctx, query, values...)
+	return err
+}
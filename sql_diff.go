@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -19,12 +20,20 @@ const (
 	DriverOracle     = "oracle"
 	DriverSqlite3    = "sqlite3"
 	DriverNotSupport = "no support"
+	kindColumn       = "kind"
+	stateColumn      = "state"
+	revisionsColumn  = "revisions"
 	// FormatDate       = "2006-01-02 15:04:05"
 )
 
+var ErrNotFound = errors.New("not found")
+
 type HistoryWriter interface {
 	Write(ctx context.Context, tx *sql.Tx, tableName string, id interface{}, diff DiffModel, approvedBy string) error
 }
+type HistoryReader interface {
+	Load(ctx context.Context, historyId string) (*DiffModel, error)
+}
 type KeyBuilder interface {
 	BuildKey(object interface{}) string
 	BuildKeyFromMap(keyMap map[string]interface{}, idNames []string) string
@@ -37,6 +46,12 @@ type DiffConfig struct {
 	ChangedBy  string `yaml:"changed_by" mapstructure:"changed_by" json:"changedBy,omitempty" gorm:"column:changedBy" bson:"changedBy,omitempty" dynamodbav:"changedBy,omitempty" firestore:"changedBy,omitempty"`
 	ApprovedBy string `yaml:"approved_by" mapstructure:"approved_by" json:"approvedBy,omitempty" gorm:"column:approvedBy" bson:"approvedBy,omitempty" dynamodbav:"approvedBy,omitempty" firestore:"approvedBy,omitempty"`
 	Timestamp  string `yaml:"timestamp" mapstructure:"timestamp" json:"timestamp,omitempty" gorm:"column:timestamp" bson:"timestamp,omitempty" dynamodbav:"timestamp,omitempty" firestore:"timestamp,omitempty"`
+	Version    string `yaml:"version" mapstructure:"version" json:"version,omitempty" gorm:"column:version" bson:"version,omitempty" dynamodbav:"version,omitempty" firestore:"version,omitempty"`
+	Kind       string `yaml:"kind" mapstructure:"kind" json:"kind,omitempty" gorm:"column:kind" bson:"kind,omitempty" dynamodbav:"kind,omitempty" firestore:"kind,omitempty"`
+	State      string `yaml:"state" mapstructure:"state" json:"state,omitempty" gorm:"column:state" bson:"state,omitempty" dynamodbav:"state,omitempty" firestore:"state,omitempty"`
+	Reason     string `yaml:"reason" mapstructure:"reason" json:"reason,omitempty" gorm:"column:reason" bson:"reason,omitempty" dynamodbav:"reason,omitempty" firestore:"reason,omitempty"`
+	Comments   string `yaml:"comments" mapstructure:"comments" json:"comments,omitempty" gorm:"column:comments" bson:"comments,omitempty" dynamodbav:"comments,omitempty" firestore:"comments,omitempty"`
+	Revisions  string `yaml:"revisions" mapstructure:"revisions" json:"revisions,omitempty" gorm:"column:revisions" bson:"revisions,omitempty" dynamodbav:"revisions,omitempty" firestore:"revisions,omitempty"`
 }
 type SqlDiffReader struct {
 	DB           *sql.DB
@@ -48,6 +63,7 @@ type SqlDiffReader struct {
 	KeyBuilder   KeyBuilder
 	BuildParam   func(i int) string
 	Driver       string
+	Comments     *SqlCommentStore
 	columnSelect string
 }
 
@@ -61,6 +77,7 @@ type SqlDiffListReader struct {
 	KeyBuilder   KeyBuilder
 	Driver       string
 	BuildParam   func(int) string
+	Comments     *SqlCommentStore
 	columnSelect string
 }
 type SqlHistoryWriter struct {
@@ -72,8 +89,38 @@ type SqlHistoryWriter struct {
 	BuildParam func(int) string
 	Generate   func() (string, error)
 }
+type SqlApprover struct {
+	DB         *sql.DB
+	Table      string
+	Entity     string
+	EntityType string
+	IdNames    []string
+	Config     DiffConfig
+	Status     StatusConfig
+	KeyBuilder KeyBuilder
+	History    HistoryWriter
+	GetUser    func(context.Context) string
+	BuildParam func(int) string
+	Driver     string
+	Columns    map[string]string
+	// AllowSelfApproval disables the four-eyes rule, which rejects an approval by the author of the change
+	AllowSelfApproval bool
+	// Events, when set, receives ChangeApproved and ChangeRejected events in the transaction of the approval
+	Events EventWriter
+	// Comments, when set, moves the comment thread of a change to its history when the change is approved or rejected
+	Comments *SqlCommentStore
+	// KeepRejected writes rejected changes to History with the state rejected and the reason of the reviewer
+	KeepRejected bool
+	// ClearApprovals, when set, deletes the approvals of a workflow when a change is sent back or rejected
+	ClearApprovals func(ctx context.Context, tx *sql.Tx, key interface{}) error
+	types          map[string]reflect.Type
+}
+type SqlApprListService struct {
+	Approver *SqlApprover
+}
 
 func NewSqlDiffReader(db *sql.DB, table string, entity string, entityType string, idNames []string, config DiffConfig, keyBuilder KeyBuilder, options...func(int) string) *SqlDiffReader {
+	config = getDefaultConfig(config)
 	columnSelect := buildQueryColumns(config)
 	driver := getDriver(db)
 	var buildParam func(int) string
@@ -86,6 +133,7 @@ func NewSqlDiffReader(db *sql.DB, table string, entity string, entityType string
 }
 
 func NewSqlDiffListReader(db *sql.DB, table string, entity string, entityType string, idNames []string, config DiffConfig, keyBuilder KeyBuilder, options...func(int) string) *SqlDiffListReader {
+	config = getDefaultConfig(config)
 	columnSelect := buildQueryColumns(config)
 	driver := getDriver(db)
 	var buildParam func(int) string
@@ -101,6 +149,31 @@ func NewSqlHistoryWriter(table string, entity string, idNames []string, config D
 	return &SqlHistoryWriter{Table: table, Entity: entity, IdNames: idNames, Config: getDefaultConfig(config), KeyBuilder: keyBuilder, BuildParam: buildParam, Generate: generate}
 }
 
+func NewSqlApprover(db *sql.DB, table string, entity string, entityType string, modelType reflect.Type, idNames []string, config DiffConfig, status *StatusConfig, keyBuilder KeyBuilder, history HistoryWriter, getUser func(context.Context) string, options ...func(int) string) *SqlApprover {
+	driver := getDriver(db)
+	var buildParam func(int) string
+	if len(options) > 0 && options[0] != nil {
+		buildParam = options[0]
+	} else {
+		buildParam = getBuild(db)
+	}
+	columns := getJsonColumns(modelType)
+	return &SqlApprover{DB: db, Table: table, Entity: entity, EntityType: entityType, IdNames: idNames, Config: getDefaultConfig(config), Status: InitializeStatus(status), KeyBuilder: keyBuilder, History: history, GetUser: getUser, BuildParam: buildParam, Driver: driver, Columns: columns, types: getJsonTypes(modelType)}
+}
+
+func NewSqlApprListService(db *sql.DB, table string, entity string, entityType string, modelType reflect.Type, idNames []string, config DiffConfig, status *StatusConfig, keyBuilder KeyBuilder, history HistoryWriter, getUser func(context.Context) string, options ...func(int) string) *SqlApprListService {
+	approver := NewSqlApprover(db, table, entity, entityType, modelType, idNames, config, status, keyBuilder, history, getUser, options...)
+	return &SqlApprListService{Approver: approver}
+}
+
+func (r SqlApprover) GetStatus() StatusConfig {
+	return r.Status
+}
+
+func (s SqlApprListService) GetStatus() StatusConfig {
+	return s.Approver.Status
+}
+
 func getDefaultConfig(config DiffConfig) DiffConfig {
 	if config.Id == "" {
 		config.Id = "id"
@@ -119,17 +192,14 @@ func (r SqlHistoryWriter) Write(ctx context.Context, tx *sql.Tx, tableName strin
 	dt := time.Now()
 
 	if len(r.IdNames) == 1 {
-		entityID = r.IdNames[0]
+		entityID = fmt.Sprint(id)
+	} else if v, ok := id.(map[string]interface{}); ok {
+		entityID = r.KeyBuilder.BuildKeyFromMap(v, r.IdNames)
+	} else if reflect.Indirect(reflect.ValueOf(id)).Kind() == reflect.Struct {
+		entityID = r.KeyBuilder.BuildKey(id)
 	} else {
-		if v, ok := id.(string); ok {
-			entityID = r.KeyBuilder.BuildKey(v)
-		}
-		if v, ok := id.(int); ok {
-			entityID = r.KeyBuilder.BuildKey(v)
-		}
-		if v, ok := id.(map[string]interface{}); ok {
-			entityID = r.KeyBuilder.BuildKeyFromMap(v, r.IdNames)
-		}
+		// the key is already built
+		entityID = fmt.Sprint(id)
 	}
 	i := 1
 	var sqlVar []interface{}
@@ -138,6 +208,7 @@ func (r SqlHistoryWriter) Write(ctx context.Context, tx *sql.Tx, tableName strin
 	strSQLs = append(strSQLs, tableName)
 	sqlParams = append(sqlParams, r.BuildParam(i))
 	sqlVar = append(sqlVar, r.Entity)
+	i++
 	if len(r.Config.ApprovedBy) > 1 {
 		strSQLs = append(strSQLs, r.Config.ApprovedBy)
 		sqlVar = append(sqlVar, approvedBy)
@@ -164,14 +235,14 @@ func (r SqlHistoryWriter) Write(ctx context.Context, tx *sql.Tx, tableName strin
 	}
 	if len(r.Config.Value) > 1 {
 		strSQLs = append(strSQLs, r.Config.Value)
-		str := fmt.Sprintf("%v", diff.Value)
+		str := toJsonString(diff.Value)
 		sqlVar = append(sqlVar, str)
 		sqlParams = append(sqlParams, r.BuildParam(i))
 		i++
 	}
 	if len(r.Config.Origin) > 1 {
 		strSQLs = append(strSQLs, r.Config.Origin)
-		str := fmt.Sprintf("%v", diff.Origin)
+		str := toJsonString(diff.Origin)
 		sqlVar = append(sqlVar, str)
 		sqlParams = append(sqlParams, r.BuildParam(i))
 		i++
@@ -188,6 +259,32 @@ func (r SqlHistoryWriter) Write(ctx context.Context, tx *sql.Tx, tableName strin
 		sqlParams = append(sqlParams, r.BuildParam(i))
 		i++
 	}
+	if len(r.Config.State) > 1 {
+		state := diff.State
+		if len(state) == 0 {
+			state = StateApproved
+		}
+		strSQLs = append(strSQLs, r.Config.State)
+		sqlVar = append(sqlVar, state)
+		sqlParams = append(sqlParams, r.BuildParam(i))
+		i++
+	}
+	if len(r.Config.Reason) > 1 {
+		strSQLs = append(strSQLs, r.Config.Reason)
+		sqlVar = append(sqlVar, diff.Reason)
+		sqlParams = append(sqlParams, r.BuildParam(i))
+		i++
+	}
+	if len(r.Config.Comments) > 1 {
+		strSQLs = append(strSQLs, r.Config.Comments)
+		comments := ""
+		if len(diff.Comments) > 0 {
+			comments = toJsonString(diff.Comments)
+		}
+		sqlVar = append(sqlVar, comments)
+		sqlParams = append(sqlParams, r.BuildParam(i))
+		i++
+	}
 	strSQL := strings.Join(strSQLs, ",")
 	sqlParam := strings.Join(sqlParams, ",")
 	query := `insert into ` + r.Table + `(` + strSQL + `) 
@@ -205,17 +302,737 @@ func (r SqlDiffReader) Diff(ctx context.Context, id interface{}) (*DiffModel, er
 		return nil, err
 	}
 	if result, ok := i.(*DiffModel); ok {
+		if r.Comments != nil {
+			result.Comments, err = r.Comments.Load(ctx, id)
+			if err != nil {
+				return nil, err
+			}
+		}
 		return result, nil
 	}
 	return nil, nil
 }
 
+func (r SqlApprover) Approve(ctx context.Context, id interface{}) (int, error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return r.Status.Error, err
+	}
+	status, err := r.approve(ctx, tx, id)
+	if err != nil || status != r.Status.Success {
+		tx.Rollback()
+		return status, err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return r.Status.Error, err
+	}
+	return status, nil
+}
+
+func (r SqlApprover) Reject(ctx context.Context, id interface{}) (int, error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return r.Status.Error, err
+	}
+	status, err := r.reject(ctx, tx, id)
+	if err != nil || status != r.Status.Success {
+		tx.Rollback()
+		return status, err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return r.Status.Error, err
+	}
+	return status, nil
+}
+
+// RequestChanges sends a staged change back to its author: the change stays staged in the state changes_requested until the author submits it again.
+func (r SqlApprover) RequestChanges(ctx context.Context, id interface{}) (int, error) {
+	if len(r.Config.State) == 0 {
+		return r.Status.Error, fmt.Errorf("state column is not configured for %s", r.Entity)
+	}
+	key, _, err := buildKeys(r.KeyBuilder, r.IdNames, id)
+	if err != nil {
+		return r.Status.Error, err
+	}
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return r.Status.Error, err
+	}
+	defer tx.Rollback()
+	diff, err := r.getStagedDiff(ctx, tx, key)
+	if err != nil {
+		return r.Status.Error, err
+	}
+	if diff == nil {
+		return r.Status.NotFound, nil
+	}
+	reviewer := ""
+	if r.GetUser != nil {
+		reviewer = r.GetUser(ctx)
+	}
+	if len(reviewer) == 0 || (!r.AllowSelfApproval && reviewer == diff.By) {
+		return r.Status.Forbidden, nil
+	}
+	query := fmt.Sprintf("update %s set %s = %s where %s = %s and %s = %s", r.Entity,
+		r.Config.State, r.BuildParam(1),
+		r.Config.Id, r.BuildParam(2),
+		r.EntityType, r.BuildParam(3))
+	_, err = tx.ExecContext(ctx, query, StateChangesRequested, key, r.Table)
+	if err != nil {
+		return r.Status.Error, err
+	}
+	if r.ClearApprovals != nil {
+		err = r.ClearApprovals(ctx, tx, key)
+		if err != nil {
+			return r.Status.Error, err
+		}
+	}
+	review := GetReview(ctx)
+	if comment := buildReviewComment(review, reviewer, CommentActionRequestChanges); comment != nil && r.Comments != nil {
+		err = r.Comments.add(ctx, tx, key, *comment)
+		if err != nil {
+			return r.Status.Error, err
+		}
+	}
+	if r.Events != nil {
+		event := r.buildReviewEvent(ChangesRequested, id, *diff, reviewer)
+		if review != nil {
+			event.Reason = review.Reason
+		}
+		err = r.Events.Write(ctx, tx, event)
+		if err != nil {
+			return r.Status.Error, err
+		}
+	}
+	err = tx.Commit()
+	if err != nil {
+		return r.Status.Error, err
+	}
+	return r.Status.Success, nil
+}
+
+// Rebase merges a stale staged change onto the current row; when there is no conflict, the staged row is updated with the current row as its origin.
+func (r SqlApprover) Rebase(ctx context.Context, id interface{}) (int, []string, error) {
+	key, keys, err := buildKeys(r.KeyBuilder, r.IdNames, id)
+	if err != nil {
+		return r.Status.Error, nil, err
+	}
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return r.Status.Error, nil, err
+	}
+	defer tx.Rollback()
+	diff, err := r.getStagedDiff(ctx, tx, key)
+	if err != nil {
+		return r.Status.Error, nil, err
+	}
+	if diff == nil {
+		return r.Status.NotFound, nil, nil
+	}
+	var origin, value map[string]interface{}
+	if s := diff.Origin.(string); len(s) > 0 {
+		origin, err = decodeJsonObject(s)
+		if err != nil {
+			return r.Status.Error, nil, err
+		}
+	}
+	if s := diff.Value.(string); len(s) > 0 {
+		value, err = decodeJsonObject(s)
+		if err != nil {
+			return r.Status.Error, nil, err
+		}
+	}
+	current, err := getCurrent(ctx, tx, r.Table, r.Columns, r.types, r.IdNames, keys, r.BuildParam)
+	if err != nil {
+		return r.Status.Error, nil, err
+	}
+	kind := GetKind(diff.Kind, origin, value)
+	if current == nil {
+		// a create is not stale while the record does not exist
+		if kind == KindCreate {
+			return r.Status.Success, nil, nil
+		}
+		return r.Status.NotFound, nil, nil
+	}
+	if kind != KindDelete && value == nil {
+		return r.Status.Error, nil, fmt.Errorf("the %s change of %v has no value", kind, id)
+	}
+	current = normalizeJson(current)
+	var merged interface{}
+	switch kind {
+	case KindCreate:
+		// the record was created meanwhile, so the fields of the create which differ from it are conflicts
+		_, conflicts := Merge(map[string]interface{}{}, current, value)
+		return r.Status.VersionError, conflicts, nil
+	case KindDelete:
+		// a delete removes the record whatever it has become, so it only takes the current row as its origin
+	default:
+		var conflicts []string
+		merged, conflicts = Merge(origin, current, value)
+		if len(conflicts) > 0 {
+			return r.Status.VersionError, conflicts, nil
+		}
+	}
+	o, err := toNullJson(current)
+	if err != nil {
+		return r.Status.Error, nil, err
+	}
+	v, err := toNullJson(merged)
+	if err != nil {
+		return r.Status.Error, nil, err
+	}
+	query := fmt.Sprintf("update %s set %s = %s, %s = %s where %s = %s and %s = %s", r.Entity,
+		r.Config.Origin, r.BuildParam(1),
+		r.Config.Value, r.BuildParam(2),
+		r.Config.Id, r.BuildParam(3),
+		r.EntityType, r.BuildParam(4))
+	_, err = tx.ExecContext(ctx, query, o, v, key, r.Table)
+	if err != nil {
+		return r.Status.Error, nil, err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return r.Status.Error, nil, err
+	}
+	return r.Status.Success, nil, nil
+}
+
+func (r SqlApprover) approve(ctx context.Context, tx *sql.Tx, id interface{}) (int, error) {
+	key, keys, err := buildKeys(r.KeyBuilder, r.IdNames, id)
+	if err != nil {
+		return r.Status.Error, err
+	}
+	diff, err := r.getStagedDiff(ctx, tx, key)
+	if err != nil {
+		return r.Status.Error, err
+	}
+	if diff == nil {
+		return r.Status.NotFound, nil
+	}
+	approvedBy := ""
+	if r.GetUser != nil {
+		approvedBy = r.GetUser(ctx)
+	}
+	if len(approvedBy) == 0 || (!r.AllowSelfApproval && approvedBy == diff.By) {
+		return r.Status.Forbidden, nil
+	}
+	// a change sent back to its author cannot be approved until it is resubmitted
+	if diff.State == StateChangesRequested {
+		return r.Status.Forbidden, nil
+	}
+	var origin, value map[string]interface{}
+	if s := diff.Origin.(string); len(s) > 0 {
+		origin, err = decodeJsonObject(s)
+		if err != nil {
+			return r.Status.Error, err
+		}
+	}
+	if s := diff.Value.(string); len(s) > 0 {
+		value, err = decodeJsonObject(s)
+		if err != nil {
+			return r.Status.Error, err
+		}
+	}
+	current, err := getCurrent(ctx, tx, r.Table, r.Columns, r.types, r.IdNames, keys, r.BuildParam)
+	if err != nil {
+		return r.Status.Error, err
+	}
+	versionName := getVersionName(r.Columns, r.Config.Version)
+	if IsStale(pickColumns(origin, r.Columns), current, versionName) {
+		return r.Status.VersionError, &VersionConflict{Id: id, Origin: origin, Current: current, Value: value}
+	}
+	kind := GetKind(diff.Kind, origin, value)
+	if kind != KindDelete && value == nil {
+		return r.Status.Error, fmt.Errorf("the %s change of %v has no value", kind, id)
+	}
+	switch kind {
+	case KindDelete:
+		where, args := buildWhere(r.Columns, r.IdNames, keys, 1, r.BuildParam)
+		_, err = tx.ExecContext(ctx, "delete from "+r.Table+" where "+where, args...)
+	default:
+		if current != nil && len(versionName) > 0 {
+			if v, ok := toFloat(normalizeJson(current)[versionName]); ok {
+				value[versionName] = int64(v) + 1
+			}
+		}
+		err = r.save(ctx, tx, keys, value)
+	}
+	if err != nil {
+		return r.Status.Error, err
+	}
+	_, err = r.deleteStagedDiff(ctx, tx, key)
+	if err != nil {
+		return r.Status.Error, err
+	}
+	err = r.review(ctx, tx, key, diff, StateApproved, approvedBy)
+	if err != nil {
+		return r.Status.Error, err
+	}
+	if r.History != nil {
+		err = r.History.Write(ctx, tx, r.EntityType, id, *diff, approvedBy)
+		if err != nil {
+			return r.Status.Error, err
+		}
+	}
+	if r.Events != nil {
+		event := Event{Type: ChangeApproved, EntityType: r.Table, EntityId: id, Origin: origin, Value: value, Kind: kind, By: diff.By, ApprovedBy: approvedBy, Reason: diff.Reason}
+		err = r.Events.Write(ctx, tx, event)
+		if err != nil {
+			return r.Status.Error, err
+		}
+	}
+	return r.Status.Success, nil
+}
+
+func (r SqlApprover) reject(ctx context.Context, tx *sql.Tx, id interface{}) (int, error) {
+	rejectedBy := ""
+	if r.GetUser != nil {
+		rejectedBy = r.GetUser(ctx)
+	}
+	return r.rejectBy(ctx, tx, id, rejectedBy, r.KeepRejected)
+}
+
+// rejectBy rejects a staged change on behalf of rejectedBy; keep writes the rejected change to History.
+func (r SqlApprover) rejectBy(ctx context.Context, tx *sql.Tx, id interface{}, rejectedBy string, keep bool) (int, error) {
+	key, _, err := buildKeys(r.KeyBuilder, r.IdNames, id)
+	if err != nil {
+		return r.Status.Error, err
+	}
+	diff, err := r.getStagedDiff(ctx, tx, key)
+	if err != nil {
+		return r.Status.Error, err
+	}
+	if diff == nil {
+		return r.Status.NotFound, nil
+	}
+	affected, err := r.deleteStagedDiff(ctx, tx, key)
+	if err != nil {
+		return r.Status.Error, err
+	}
+	if affected <= 0 {
+		return r.Status.NotFound, nil
+	}
+	if r.ClearApprovals != nil {
+		err = r.ClearApprovals(ctx, tx, key)
+		if err != nil {
+			return r.Status.Error, err
+		}
+	}
+	err = r.review(ctx, tx, key, diff, StateRejected, rejectedBy)
+	if err != nil {
+		return r.Status.Error, err
+	}
+	if r.History != nil && keep {
+		err = r.History.Write(ctx, tx, r.EntityType, id, *diff, rejectedBy)
+		if err != nil {
+			return r.Status.Error, err
+		}
+	}
+	if r.Events != nil {
+		err = r.Events.Write(ctx, tx, r.buildReviewEvent(ChangeRejected, id, *diff, rejectedBy))
+		if err != nil {
+			return r.Status.Error, err
+		}
+	}
+	return r.Status.Success, nil
+}
+
+func (r SqlApprover) buildReviewEvent(eventType string, id interface{}, diff DiffModel, reviewer string) Event {
+	event := Event{Type: eventType, EntityType: r.Table, EntityId: id, By: diff.By, ApprovedBy: reviewer, Reason: diff.Reason}
+	if s, ok := diff.Origin.(string); ok && len(s) > 0 {
+		event.Origin, _ = decodeJsonObject(s)
+	}
+	if s, ok := diff.Value.(string); ok && len(s) > 0 {
+		event.Value, _ = decodeJsonObject(s)
+	}
+	event.Kind = GetKind(diff.Kind, event.Origin, event.Value)
+	return event
+}
+
+// review sets the state and the reason of the reviewer on diff and closes the comment thread of the change, adding the comment of the reviewer.
+func (r SqlApprover) review(ctx context.Context, tx *sql.Tx, key interface{}, diff *DiffModel, state string, reviewer string) error {
+	diff.State = state
+	review := GetReview(ctx)
+	if review != nil {
+		diff.Reason = review.Reason
+	}
+	action := CommentActionApprove
+	if state == StateRejected {
+		action = CommentActionReject
+	}
+	if r.Comments != nil {
+		thread, err := r.Comments.load(ctx, tx, key)
+		if err != nil {
+			return err
+		}
+		err = r.Comments.clear(ctx, tx, key)
+		if err != nil {
+			return err
+		}
+		diff.Comments = thread
+	}
+	if comment := buildReviewComment(review, reviewer, action); comment != nil {
+		diff.Comments = append(diff.Comments, *comment)
+	}
+	return nil
+}
+
+func (r SqlApprover) getStagedDiff(ctx context.Context, tx *sql.Tx, key interface{}) (*DiffModel, error) {
+	query := fmt.Sprintf("select %s from %s where %s = %s and %s = %s", buildQueryColumns(r.Config), r.Entity,
+		r.Config.Id, r.BuildParam(1),
+		r.EntityType, r.BuildParam(2))
+	var id, origin, value, by, kind, state, revisions sql.NullString
+	dest := []interface{}{&id, &origin, &value}
+	if len(getByColumn(r.Config)) > 0 {
+		dest = append(dest, &by)
+	}
+	if len(r.Config.Kind) > 0 {
+		dest = append(dest, &kind)
+	}
+	if len(r.Config.State) > 0 {
+		dest = append(dest, &state)
+	}
+	if len(r.Config.Revisions) > 0 {
+		dest = append(dest, &revisions)
+	}
+	err := tx.QueryRowContext(ctx, query, key, r.Table).Scan(dest...)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &DiffModel{Id: key, Origin: origin.String, Value: value.String, By: by.String, Kind: kind.String, State: state.String}, nil
+}
+
+func (r SqlApprover) deleteStagedDiff(ctx context.Context, tx *sql.Tx, key interface{}) (int64, error) {
+	query := fmt.Sprintf("delete from %s where %s = %s and %s = %s", r.Entity,
+		r.Config.Id, r.BuildParam(1),
+		r.EntityType, r.BuildParam(2))
+	res, err := tx.ExecContext(ctx, query, key, r.Table)
+	if err != nil {
+		return -1, err
+	}
+	return res.RowsAffected()
+}
+
+func (r SqlApprover) save(ctx context.Context, tx *sql.Tx, keys map[string]interface{}, value map[string]interface{}) error {
+	where, args := buildWhere(r.Columns, r.IdNames, keys, 1, r.BuildParam)
+	var count int64
+	err := tx.QueryRowContext(ctx, "select count(*) from "+r.Table+" where "+where, args...).Scan(&count)
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0)
+	for name := range value {
+		if _, ok := keys[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	var columns []string
+	var values []interface{}
+	for _, name := range names {
+		// the names come from the staged value, so only the columns of the model are written
+		column, ok := r.Columns[name]
+		if !ok && len(name) > 0 && name == r.Config.Version {
+			column, ok = name, true
+		}
+		if !ok {
+			return fmt.Errorf("%s is not a field of %s", name, r.Table)
+		}
+		v, err := toColumnValue(value[name])
+		if err != nil {
+			return err
+		}
+		columns = append(columns, column)
+		values = append(values, v)
+	}
+	if count > 0 {
+		if len(columns) == 0 {
+			return nil
+		}
+		sets := make([]string, 0)
+		for i, column := range columns {
+			sets = append(sets, column+" = "+r.BuildParam(i+1))
+		}
+		where, args = buildWhere(r.Columns, r.IdNames, keys, len(columns)+1, r.BuildParam)
+		query := fmt.Sprintf("update %s set %s where %s", r.Table, strings.Join(sets, ","), where)
+		_, err = tx.ExecContext(ctx, query, append(values, args...)...)
+		return err
+	}
+	for _, name := range r.IdNames {
+		columns = append(columns, getColumn(r.Columns, name))
+		values = append(values, keys[name])
+	}
+	query := fmt.Sprintf("insert into %s(%s) values (%s)", r.Table, strings.Join(columns, ","), buildParameters(len(columns), r.BuildParam))
+	_, err = tx.ExecContext(ctx, query, values...)
+	return err
+}
+
+func (s SqlApprListService) Approve(ctx context.Context, ids interface{}) (int, error) {
+	results, err := s.ApproveList(ctx, ids)
+	return s.getStatus(results, err)
+}
+
+func (s SqlApprListService) Reject(ctx context.Context, ids interface{}) (int, error) {
+	results, err := s.RejectList(ctx, ids)
+	return s.getStatus(results, err)
+}
+
+func (s SqlApprListService) ApproveList(ctx context.Context, ids interface{}) ([]ApprResult, error) {
+	return s.execute(ctx, ids, s.Approver.approve)
+}
+
+func (s SqlApprListService) RejectList(ctx context.Context, ids interface{}) ([]ApprResult, error) {
+	return s.execute(ctx, ids, s.Approver.reject)
+}
+
+func (s SqlApprListService) execute(ctx context.Context, ids interface{}, exec func(context.Context, *sql.Tx, interface{}) (int, error)) ([]ApprResult, error) {
+	list, err := toList(ids)
+	if err != nil {
+		return nil, err
+	}
+	r := s.Approver
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]ApprResult, 0)
+	for _, id := range list {
+		if _, _, er1 := buildKeys(r.KeyBuilder, r.IdNames, id); er1 != nil {
+			results = append(results, ApprResult{Id: id, Status: r.Status.Error, Error: er1.Error()})
+			continue
+		}
+		// each id runs in a savepoint, so that a failed id leaves nothing in the transaction and the results match what is committed
+		if _, er2 := tx.ExecContext(ctx, buildSavepoint(r.Driver, "appr")); er2 != nil {
+			tx.Rollback()
+			return nil, er2
+		}
+		status, er3 := exec(ctx, tx, id)
+		if er3 == nil && status == r.Status.Success {
+			if query := buildReleaseSavepoint(r.Driver, "appr"); len(query) > 0 {
+				if _, er4 := tx.ExecContext(ctx, query); er4 != nil {
+					tx.Rollback()
+					return nil, er4
+				}
+			}
+			results = append(results, ApprResult{Id: id, Status: status})
+			continue
+		}
+		if _, er4 := tx.ExecContext(ctx, buildRollbackSavepoint(r.Driver, "appr")); er4 != nil {
+			tx.Rollback()
+			return nil, er4
+		}
+		result := ApprResult{Id: id, Status: status}
+		if er3 != nil {
+			result.Error = er3.Error()
+			var conflict *VersionConflict
+			if errors.As(er3, &conflict) {
+				result.Conflict = conflict
+			} else {
+				result.Status = r.Status.Error
+			}
+		}
+		results = append(results, result)
+	}
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func buildSavepoint(driver string, name string) string {
+	if driver == DriverMssql {
+		return "save transaction " + name
+	}
+	return "savepoint " + name
+}
+
+func buildRollbackSavepoint(driver string, name string) string {
+	if driver == DriverMssql {
+		return "rollback transaction " + name
+	}
+	return "rollback to savepoint " + name
+}
+
+// buildReleaseSavepoint returns an empty string for Oracle and SQL Server, which have no release statement.
+func buildReleaseSavepoint(driver string, name string) string {
+	if driver == DriverOracle || driver == DriverMssql {
+		return ""
+	}
+	return "release savepoint " + name
+}
+
+func (s SqlApprListService) getStatus(results []ApprResult, err error) (int, error) {
+	if err != nil {
+		return s.Approver.Status.Error, err
+	}
+	for _, result := range results {
+		if result.Status != s.Approver.Status.Success {
+			return result.Status, nil
+		}
+	}
+	return s.Approver.Status.Success, nil
+}
+
+func toList(ids interface{}) ([]interface{}, error) {
+	if ids == nil {
+		return nil, errors.New("failed keys nil")
+	}
+	v := reflect.Indirect(reflect.ValueOf(ids))
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, errors.New("ids must be a slice")
+	}
+	list := make([]interface{}, 0)
+	for i := 0; i < v.Len(); i++ {
+		list = append(list, v.Index(i).Interface())
+	}
+	return list, nil
+}
+
+func buildKeys(keyBuilder KeyBuilder, idNames []string, id interface{}) (interface{}, map[string]interface{}, error) {
+	if keyMap, ok := id.(map[string]interface{}); ok {
+		key := keyBuilder.BuildKeyFromMap(keyMap, idNames)
+		if key == "" {
+			return nil, nil, errors.New("failed to build key")
+		}
+		return key, keyMap, nil
+	}
+	if v := reflect.Indirect(reflect.ValueOf(id)); v.Kind() == reflect.Struct {
+		key := keyBuilder.BuildKey(id)
+		if key == "" {
+			return nil, nil, errors.New("failed to build key")
+		}
+		keyMap := make(map[string]interface{})
+		for i := 0; i < v.NumField(); i++ {
+			jsonName := strings.Split(v.Type().Field(i).Tag.Get("json"), ",")[0]
+			if find(idNames, jsonName) {
+				keyMap[jsonName] = v.Field(i).Interface()
+			}
+		}
+		return key, keyMap, nil
+	}
+	if len(idNames) != 1 {
+		return nil, nil, errors.New("invalid id: composite key must be a map")
+	}
+	return id, map[string]interface{}{idNames[0]: id}, nil
+}
+
+func buildWhere(columns map[string]string, idNames []string, keys map[string]interface{}, start int, buildParam func(int) string) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	for i, name := range idNames {
+		conditions = append(conditions, getColumn(columns, name)+" = "+buildParam(start+i))
+		args = append(args, keys[name])
+	}
+	return strings.Join(conditions, " and "), args
+}
+
+// pickColumns returns the fields of m which are columns of the model, so that only they are compared with the current row.
+func pickColumns(m map[string]interface{}, columns map[string]string) map[string]interface{} {
+	if m == nil || len(columns) == 0 {
+		return m
+	}
+	result := make(map[string]interface{})
+	for name, v := range m {
+		if _, ok := columns[name]; ok {
+			result[name] = v
+		}
+	}
+	return result
+}
+
+func getVersionName(columns map[string]string, version string) string {
+	for jsonName, column := range columns {
+		if strings.EqualFold(column, version) {
+			return jsonName
+		}
+	}
+	return version
+}
+
+func getColumn(columns map[string]string, jsonName string) string {
+	if column, ok := columns[jsonName]; ok {
+		return column
+	}
+	return jsonName
+}
+
+func getJsonColumns(modelType reflect.Type) map[string]string {
+	columns := make(map[string]string)
+	if modelType == nil {
+		return columns
+	}
+	if modelType.Kind() == reflect.Ptr {
+		modelType = modelType.Elem()
+	}
+	for i := 0; i < modelType.NumField(); i++ {
+		jsonName := strings.Split(modelType.Field(i).Tag.Get("json"), ",")[0]
+		if len(jsonName) == 0 || jsonName == "-" {
+			continue
+		}
+		if column, ok := getColumnNameByIndex(modelType, i); ok {
+			columns[jsonName] = column
+		}
+	}
+	return columns
+}
+
+func decodeJsonObject(str string) (map[string]interface{}, error) {
+	decoder := json.NewDecoder(strings.NewReader(str))
+	decoder.UseNumber()
+	var m map[string]interface{}
+	err := decoder.Decode(&m)
+	return m, err
+}
+
+func toJsonString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case *string:
+		return *v
+	case nil:
+		return ""
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(b)
+}
+
+func toColumnValue(value interface{}) (interface{}, error) {
+	switch value.(type) {
+	case map[string]interface{}, []interface{}:
+		b, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		return string(b), nil
+	default:
+		return value, nil
+	}
+}
+
 func (c SqlDiffListReader) Diff(ctx context.Context, ids interface{}) (*[]DiffModel, error) {
 	i, err := c.getEntityByIds(ctx, c.KeyBuilder, ids, c.IdNames)
 	if err != nil {
 		return nil, err
 	}
 	if result, ok := i.(*[]DiffModel); ok {
+		if c.Comments != nil {
+			for j := range *result {
+				(*result)[j].Comments, err = c.Comments.Load(ctx, (*result)[j].Id)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
 		return result, nil
 	}
 	return nil, nil
@@ -237,6 +1054,9 @@ func (r SqlDiffReader) getEntityById(ctx context.Context, key interface{}, idNam
 		r.EntityType, r.BuildParam(2))
 	err := QueryDiff(ctx, r.DB, &result, querySql, key, r.Table)
 	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, nil
+		}
 		return nil, err
 	}
 	if saveValueId != nil && len(idNames) > 1 {
@@ -264,12 +1084,28 @@ func buildQueryColumns(config DiffConfig) string {
 	if config.Value != "" {
 		sqlsel = append(sqlsel, config.Value+" as "+colDiffModel[2])
 	}
-	if config.ApprovedBy != "" {
-		sqlsel = append(sqlsel, config.ApprovedBy+" as "+colDiffModel[3])
+	if by := getByColumn(config); by != "" {
+		sqlsel = append(sqlsel, by+" as "+colDiffModel[3])
+	}
+	if config.Kind != "" {
+		sqlsel = append(sqlsel, config.Kind+" as "+kindColumn)
+	}
+	if config.State != "" {
+		sqlsel = append(sqlsel, config.State+" as "+stateColumn)
+	}
+	if config.Revisions != "" {
+		sqlsel = append(sqlsel, config.Revisions+" as "+revisionsColumn)
 	}
 	return strings.Join(sqlsel, ",")
 }
 
+func getByColumn(config DiffConfig) string {
+	if config.ChangedBy != "" {
+		return config.ChangedBy
+	}
+	return config.ApprovedBy
+}
+
 func getDiffColumnNames() []string {
 	ids := make([]string, 0)
 	objectValue := reflect.Indirect(reflect.ValueOf(DiffModel{}))
@@ -291,11 +1127,11 @@ func (c SqlDiffListReader) getEntityByIds(ctx context.Context, keyBuilder KeyBui
 		if len(idNames) > 1 {
 			for i := 0; i < n; i++ {
 				itemStruct := keysInterface.Index(i).Interface()
-				entityId := keyBuilder.BuildKey(itemStruct)
-				listIds[entityId] = itemStruct
-				if entityId == "" {
-					return nil, errors.New("failed to build key")
+				entityId, _, err := buildKeys(keyBuilder, idNames, itemStruct)
+				if err != nil {
+					return nil, err
 				}
+				listIds[fmt.Sprint(entityId)] = itemStruct
 				arrayKeys = append(arrayKeys, entityId)
 			}
 		} else {
@@ -318,8 +1154,7 @@ func (c SqlDiffListReader) getEntityByIds(ctx context.Context, keyBuilder KeyBui
 	err := QueryDiffs(ctx, c.DB, &results, querySql, args...)
 	// map object id
 	for i, result := range results {
-		id := result.Id.(*string)
-		if idObject, ok := listIds[*id]; ok {
+		if idObject, ok := listIds[fmt.Sprint(result.Id)]; ok {
 			results[i].Id = idObject
 		}
 	}
@@ -378,7 +1213,7 @@ func QueryDiff(ctx context.Context, db *sql.DB, result *DiffModel, sql string, v
 		sizeCol := len(cols)
 		vals := createValuesByType(types, sizeCol)
 		err := rows.Scan(vals...)
-		mapToModel(vals, result)
+		mapToModel(cols, vals, result)
 		return err
 	}
 	// If the database is being written to ensure to check for Close
@@ -392,35 +1227,115 @@ func QueryDiff(ctx context.Context, db *sql.DB, result *DiffModel, sql string, v
 	if err := rows.Err(); err != nil {
 		return err
 	}
-	return errors.New("not found")
+	return ErrNotFound
 }
 
-func mapToModel(vals []interface{}, result *DiffModel) {
-	result.Id = vals[0]
+func mapToModel(cols []string, vals []interface{}, result *DiffModel) {
 	n := len(vals)
-	origin, _ := convertStringToMap(vals[1].(*string))
-	value, _ := convertStringToMap(vals[2].(*string))
-	result.Origin = origin
-	result.Value = value
-	if n > 3 && vals[3] != nil {
-		if v, ok := vals[3].(string); ok {
-			result.By = v
+	if n < 3 {
+		return
+	}
+	result.Id, _ = getScannedValue(vals[0])
+	var origin, value *map[string]interface{}
+	if s, ok := getScannedString(vals[1]); ok && len(s) > 0 {
+		origin, _ = convertStringToMap(&s)
+	}
+	if s, ok := getScannedString(vals[2]); ok && len(s) > 0 {
+		value, _ = convertStringToMap(&s)
+	}
+	// a NULL origin is a new record and a NULL value is a deletion; keep them as nil instead of a pointer to a nil map
+	if origin != nil {
+		result.Origin = origin
+	}
+	if value != nil {
+		result.Value = value
+	}
+	result.Changes = BuildChanges(result.Origin, result.Value)
+	for i := 3; i < n; i++ {
+		v, ok := getScannedString(vals[i])
+		if !ok {
+			continue
 		}
-		if v, ok := vals[3].(*string); ok {
-			result.By = *v
+		if i < len(cols) && strings.EqualFold(cols[i], kindColumn) {
+			result.Kind = v
+		} else if i < len(cols) && strings.EqualFold(cols[i], stateColumn) {
+			result.State = v
+		} else if i < len(cols) && strings.EqualFold(cols[i], revisionsColumn) {
+			result.Revisions, _ = decodeRevisions(v, result.Value)
+		} else if i == 3 {
+			result.By = v
 		}
 	}
+	result.Kind = GetKind(result.Kind, result.Origin, result.Value)
 }
 
 func createValuesByType(types []*sql.ColumnType, sizeCol int) []interface{} {
 	vals := make([]interface{}, sizeCol)
-	for i := range types {
-		//TODO check add type if any type another
-		vals[i] = new(string)
+	for i := range vals {
+		if i < len(types) && types[i] != nil {
+			vals[i] = createValueByType(types[i])
+		} else {
+			vals[i] = new(sql.NullString)
+		}
 	}
 	return vals
 }
 
+func createValueByType(columnType *sql.ColumnType) interface{} {
+	switch strings.ToUpper(columnType.DatabaseTypeName()) {
+	case "INT", "INTEGER", "INT2", "INT4", "INT8", "SMALLINT", "MEDIUMINT", "BIGINT", "TINYINT", "SERIAL", "BIGSERIAL", "UNSIGNED INT":
+		return new(sql.NullInt64)
+	case "FLOAT", "FLOAT4", "FLOAT8", "DOUBLE", "REAL", "BINARY_FLOAT", "BINARY_DOUBLE":
+		return new(sql.NullFloat64)
+	case "BOOL", "BOOLEAN":
+		return new(sql.NullBool)
+	case "":
+		if scanType := columnType.ScanType(); scanType != nil {
+			switch scanType.Kind() {
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+				return new(sql.NullInt64)
+			case reflect.Float32, reflect.Float64:
+				return new(sql.NullFloat64)
+			case reflect.Bool:
+				return new(sql.NullBool)
+			}
+		}
+	}
+	// text, JSON, JSONB, BLOB, CLOB, NUMERIC and UUID columns are read as strings: drivers return them as string or []byte
+	return new(sql.NullString)
+}
+
+func getScannedValue(v interface{}) (interface{}, bool) {
+	switch x := v.(type) {
+	case *sql.NullString:
+		return x.String, x.Valid
+	case *sql.NullInt64:
+		return x.Int64, x.Valid
+	case *sql.NullFloat64:
+		return x.Float64, x.Valid
+	case *sql.NullBool:
+		return x.Bool, x.Valid
+	case *string:
+		if x == nil {
+			return nil, false
+		}
+		return *x, true
+	default:
+		return v, v != nil
+	}
+}
+
+func getScannedString(v interface{}) (string, bool) {
+	x, ok := getScannedValue(v)
+	if !ok || x == nil {
+		return "", false
+	}
+	if s, ok := x.(string); ok {
+		return s, true
+	}
+	return fmt.Sprint(x), true
+}
+
 func convertStringToMap(str *string) (*map[string]interface{}, error) {
 	reader := strings.NewReader(*str)
 	var p map[string]interface{}
@@ -450,7 +1365,7 @@ func QueryDiffs(ctx context.Context, db *sql.DB, results *[]DiffModel, sql strin
 		if err != nil {
 			return err
 		}
-		mapToModel(vals, &result)
+		mapToModel(cols, vals, &result)
 		*results = append(*results, result)
 	}
 	// If the database is being written to ensure to check for Close
@@ -505,11 +1420,10 @@ func (b *DefaultKeyBuilder) BuildKeyFromMap(keyMap map[string]interface{}, idNam
 		if keyVal, exist := keyMap[key]; !exist {
 			values = append(values, "")
 		} else {
-			str, ok := keyVal.(string)
-			if !ok {
+			if keyVal == nil {
 				return ""
 			}
-			values = append(values, str)
+			values = append(values, fmt.Sprint(keyVal))
 		}
 	}
 	return strings.Join(values, "-")
@@ -0,0 +1,171 @@
+package diff
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+type SqlDiffSearcher struct {
+	DB         *sql.DB
+	Entity     string
+	EntityType string
+	Config     DiffConfig
+	BuildParam func(int) string
+	Driver     string
+}
+
+func NewSqlDiffSearcher(db *sql.DB, entity string, entityType string, config DiffConfig, options ...func(int) string) *SqlDiffSearcher {
+	driver := getDriver(db)
+	var buildParam func(int) string
+	if len(options) > 0 && options[0] != nil {
+		buildParam = options[0]
+	} else {
+		buildParam = getBuild(db)
+	}
+	return &SqlDiffSearcher{DB: db, Entity: entity, EntityType: entityType, Config: getDefaultConfig(config), BuildParam: buildParam, Driver: driver}
+}
+
+func (s SqlDiffSearcher) Search(ctx context.Context, filter DiffFilter) ([]DiffModel, int64, error) {
+	var conditions []string
+	var args []interface{}
+	if len(filter.EntityType) > 0 {
+		conditions = append(conditions, fmt.Sprintf("%s in (%s)", s.EntityType, buildParametersFrom(len(args)+1, len(filter.EntityType), s.BuildParam)))
+		for _, entityType := range filter.EntityType {
+			args = append(args, entityType)
+		}
+	}
+	if len(filter.Id) > 0 {
+		conditions = append(conditions, s.Config.Id+" = "+s.BuildParam(len(args)+1))
+		args = append(args, filter.Id)
+	}
+	by := getByColumn(s.Config)
+	if len(filter.By) > 0 && len(by) > 0 {
+		conditions = append(conditions, by+" = "+s.BuildParam(len(args)+1))
+		args = append(args, filter.By)
+	}
+	if len(filter.State) > 0 && len(s.Config.State) > 0 {
+		conditions = append(conditions, s.Config.State+" = "+s.BuildParam(len(args)+1))
+		args = append(args, filter.State)
+	}
+	if filter.From != nil && len(s.Config.Timestamp) > 0 {
+		conditions = append(conditions, s.Config.Timestamp+" >= "+s.BuildParam(len(args)+1))
+		args = append(args, *filter.From)
+	}
+	if filter.To != nil && len(s.Config.Timestamp) > 0 {
+		conditions = append(conditions, s.Config.Timestamp+" <= "+s.BuildParam(len(args)+1))
+		args = append(args, *filter.To)
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = " where " + strings.Join(conditions, " and ")
+	}
+	var total int64
+	err := s.DB.QueryRowContext(ctx, "select count(*) from "+s.Entity+where, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+	columns := []string{s.Config.Id, s.Config.Origin, s.Config.Value, s.EntityType}
+	if len(by) > 0 {
+		columns = append(columns, by)
+	}
+	if len(s.Config.Timestamp) > 0 {
+		columns = append(columns, s.Config.Timestamp)
+	}
+	if len(s.Config.Kind) > 0 {
+		columns = append(columns, s.Config.Kind)
+	}
+	if len(s.Config.State) > 0 {
+		columns = append(columns, s.Config.State)
+	}
+	if len(s.Config.Revisions) > 0 {
+		columns = append(columns, s.Config.Revisions)
+	}
+	query := fmt.Sprintf("select %s from %s%s order by %s", strings.Join(columns, ","), s.Entity, where, s.buildSort(filter.Sort))
+	query = query + buildPaging(s.Driver, filter.Page, filter.Limit)
+	rows, err := s.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	list := make([]DiffModel, 0)
+	for rows.Next() {
+		var id, origin, value, entityType, changedBy, kind, state, revisions sql.NullString
+		var timestamp sql.NullTime
+		dest := []interface{}{&id, &origin, &value, &entityType}
+		if len(by) > 0 {
+			dest = append(dest, &changedBy)
+		}
+		if len(s.Config.Timestamp) > 0 {
+			dest = append(dest, &timestamp)
+		}
+		if len(s.Config.Kind) > 0 {
+			dest = append(dest, &kind)
+		}
+		if len(s.Config.State) > 0 {
+			dest = append(dest, &state)
+		}
+		if len(s.Config.Revisions) > 0 {
+			dest = append(dest, &revisions)
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, 0, err
+		}
+		result := DiffModel{Id: id.String, By: changedBy.String, EntityType: entityType.String, State: state.String}
+		if origin.Valid && len(origin.String) > 0 {
+			result.Origin, _ = convertStringToMap(&origin.String)
+		}
+		if value.Valid && len(value.String) > 0 {
+			result.Value, _ = convertStringToMap(&value.String)
+		}
+		result.Changes = BuildChanges(result.Origin, result.Value)
+		result.Kind = GetKind(kind.String, result.Origin, result.Value)
+		if result.Revisions, err = decodeRevisions(revisions.String, result.Value); err != nil {
+			return nil, 0, err
+		}
+		if timestamp.Valid {
+			t := timestamp.Time
+			result.Timestamp = &t
+		}
+		list = append(list, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return list, total, nil
+}
+
+// buildSort maps a sort expression such as "-timestamp,id" to columns, ignoring unknown fields.
+func (s SqlDiffSearcher) buildSort(sort string) string {
+	columns := map[string]string{"id": s.Config.Id, "entityType": s.EntityType, "by": getByColumn(s.Config), "timestamp": s.Config.Timestamp}
+	var orders []string
+	for _, field := range strings.Split(sort, ",") {
+		field = strings.TrimSpace(field)
+		direction := ""
+		if strings.HasPrefix(field, "-") {
+			field = field[1:]
+			direction = " desc"
+		} else if strings.HasPrefix(field, "+") {
+			field = field[1:]
+		}
+		if column, ok := columns[field]; ok && len(column) > 0 {
+			orders = append(orders, column+direction)
+		}
+	}
+	if len(orders) == 0 {
+		if len(s.Config.Timestamp) > 0 {
+			return s.Config.Timestamp + " desc"
+		}
+		return s.Config.Id
+	}
+	return strings.Join(orders, ",")
+}
+
+func buildParametersFrom(start int, numCol int, buildParam func(int) string) string {
+	var arrValue []string
+	for i := 0; i < numCol; i++ {
+		arrValue = append(arrValue, buildParam(start+i))
+	}
+	return strings.Join(arrValue, ",")
+}
@@ -0,0 +1,98 @@
+package diff_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	d "github.com/core-go/diff"
+)
+
+func TestSqlDiffSearcher(t *testing.T) {
+	f := newFixture(t)
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	rows := []struct {
+		id, entityType, origin, value, by string
+		ts                                time.Time
+	}{
+		{"1", "item", `{"name":"a"}`, `{"name":"b"}`, "maker", day},
+		{"2", "item", "", `{"name":"c"}`, "other", day.Add(time.Hour)},
+		{"3", "user", `{"name":"d"}`, "", "maker", day.Add(2 * time.Hour)},
+	}
+	for _, row := range rows {
+		var origin, value interface{}
+		if len(row.origin) > 0 {
+			origin = row.origin
+		}
+		if len(row.value) > 0 {
+			value = row.value
+		}
+		if _, err := f.DB.Exec("insert into pending(id, entitytype, origin, value, changedby, ts) values (?, ?, ?, ?, ?, ?)", row.id, row.entityType, origin, value, row.by, row.ts); err != nil {
+			t.Fatal(err)
+		}
+	}
+	from, to := day.Add(30*time.Minute), day.Add(90*time.Minute)
+	tests := []struct {
+		name   string
+		filter d.DiffFilter
+		ids    []string
+		total  int64
+	}{
+		{"no filter, latest first", d.DiffFilter{}, []string{"3", "2", "1"}, 3},
+		{"entity types", d.DiffFilter{EntityType: []string{"item"}}, []string{"2", "1"}, 2},
+		{"several entity types", d.DiffFilter{EntityType: []string{"item", "user"}, Sort: "id"}, []string{"1", "2", "3"}, 3},
+		{"id", d.DiffFilter{Id: "2"}, []string{"2"}, 1},
+		{"by", d.DiffFilter{By: "maker", Sort: "id"}, []string{"1", "3"}, 2},
+		{"entity type and by", d.DiffFilter{EntityType: []string{"item"}, By: "maker"}, []string{"1"}, 1},
+		{"from", d.DiffFilter{From: &from}, []string{"3", "2"}, 2},
+		{"from and to", d.DiffFilter{From: &from, To: &to}, []string{"2"}, 1},
+		{"no match", d.DiffFilter{By: "nobody"}, []string{}, 0},
+		{"descending sort", d.DiffFilter{Sort: "-id"}, []string{"3", "2", "1"}, 3},
+		{"sort by several fields", d.DiffFilter{Sort: "entityType, -timestamp"}, []string{"2", "1", "3"}, 3},
+		{"unknown sort fields are ignored", d.DiffFilter{Sort: "name;drop table pending,+id"}, []string{"1", "2", "3"}, 3},
+		{"first page", d.DiffFilter{Sort: "id", Page: 1, Limit: 2}, []string{"1", "2"}, 3},
+		{"second page", d.DiffFilter{Sort: "id", Page: 2, Limit: 2}, []string{"3"}, 3},
+	}
+	searcher := d.NewSqlDiffSearcher(f.DB, "pending", "entitytype", f.Config)
+	for _, test := range tests {
+		list, total, err := searcher.Search(context.Background(), test.filter)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		ids := make([]string, 0)
+		for _, model := range list {
+			ids = append(ids, model.Id.(string))
+		}
+		if !reflect.DeepEqual(ids, test.ids) || total != test.total {
+			t.Errorf("%s: ids = %v, total = %d, want %v, %d", test.name, ids, total, test.ids, test.total)
+		}
+	}
+}
+
+func TestSqlDiffSearcherReadsCreatesAndDeletes(t *testing.T) {
+	f := newFixture(t,
+		`insert into pending(id, entitytype, origin, value, changedby) values ('1', 'item', null, '{"name":"a"}', 'maker')`,
+		`insert into pending(id, entitytype, origin, value, changedby) values ('2', 'item', '{"name":"b"}', null, 'maker')`,
+	)
+	list, _, err := d.NewSqlDiffSearcher(f.DB, "pending", "entitytype", f.Config).Search(context.Background(), d.DiffFilter{Sort: "id"})
+	if err != nil || len(list) != 2 {
+		t.Fatalf("search = %v %v", list, err)
+	}
+	tests := []struct {
+		kind    string
+		changes string
+	}{
+		{d.KindCreate, d.ChangeAdded},
+		{d.KindDelete, d.ChangeRemoved},
+	}
+	for i, test := range tests {
+		model := list[i]
+		if model.Kind != test.kind || model.By != "maker" || model.Timestamp != nil {
+			t.Errorf("%v: kind = %s, by = %s, timestamp = %v, want %s, maker and no timestamp", model.Id, model.Kind, model.By, model.Timestamp, test.kind)
+		}
+		if len(model.Changes) != 1 || model.Changes[0].Kind != test.changes {
+			t.Errorf("%v: changes = %+v, want one %s field", model.Id, model.Changes, test.changes)
+		}
+	}
+}
@@ -0,0 +1,336 @@
+package diff_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	d "github.com/core-go/diff"
+	"github.com/core-go/diff/difftest"
+)
+
+type User struct {
+	Id      string `json:"id" gorm:"column:id;primary_key"`
+	Name    string `json:"name" gorm:"column:name"`
+	Version int    `json:"version" gorm:"column:version"`
+}
+
+type Flag struct {
+	Id     string `json:"id" gorm:"column:id;primary_key"`
+	Name   string `json:"name" gorm:"column:name"`
+	Active bool   `json:"active" gorm:"column:active"`
+}
+
+type CodedUser struct {
+	Id      string `json:"id" gorm:"column:id;primary_key"`
+	Code    string `json:"code" gorm:"column:code;primary_key"`
+	Name    string `json:"name" gorm:"column:name"`
+	Version int    `json:"version" gorm:"column:version"`
+}
+
+func TestApproveListWritesHistoryOfStructIds(t *testing.T) {
+	db := openDB(t,
+		"create table items(id text, code text, name text)",
+		"create table pending(id text, entitytype text, origin text, value text, changedby text, ts timestamp)",
+		"create table history(historyid text, entitytype text, id text, origin text, value text, changedby text, approvedby text, ts timestamp)",
+		"insert into items values('a', 'x', 'A')",
+	)
+	ctx := context.Background()
+	user := "maker"
+	getUser := func(context.Context) string { return user }
+	idNames := []string{"id", "code"}
+	modelType := reflect.TypeOf(Item{})
+	config := d.DiffConfig{ChangedBy: "changedby", Timestamp: "ts"}
+	keyBuilder := d.NewDefaultKeyBuilder()
+	history := d.NewSqlHistoryWriter("history", "items", idNames, d.DiffConfig{HistoryId: "historyid", ChangedBy: "changedby", ApprovedBy: "approvedby", Timestamp: "ts"}, keyBuilder, func(int) string { return "?" }, func() (string, error) { return "h1", nil })
+	submitter := d.NewSqlSubmitter(db, "items", "pending", "entitytype", modelType, idNames, config, nil, keyBuilder, getUser)
+	if status, err := submitter.Submit(ctx, Item{Id: "a", Code: "x"}, map[string]interface{}{"name": "B"}); err != nil || status != 1 {
+		t.Fatalf("submit: %d %v", status, err)
+	}
+	user = "checker"
+	service := d.NewSqlApprListService(db, "items", "pending", "entitytype", modelType, idNames, config, nil, keyBuilder, history, getUser)
+	results, err := service.ApproveList(ctx, []Item{{Id: "a", Code: "x"}})
+	if err != nil || len(results) != 1 || results[0].Status != 1 {
+		t.Fatalf("approve list: %v %v", results, err)
+	}
+	var id string
+	if err := db.QueryRow("select id from history").Scan(&id); err != nil {
+		t.Fatal(err)
+	}
+	if id != "a-x" {
+		t.Errorf("history id = %q, want %q", id, "a-x")
+	}
+}
+
+func TestApproveListKeepsTheIdsWhichSucceed(t *testing.T) {
+	f := newFixture(t,
+		"insert into items values('a', 'x', 'A')",
+		"insert into items values('b', 'x', 'B')",
+		"insert into items values('c', 'x', 'C')",
+		`create trigger full before insert on history when new.value like '%B2%' begin select raise(abort, 'history is full'); end`,
+	)
+	ids := []Item{{Id: "a", Code: "x"}, {Id: "b", Code: "x"}, {Id: "c", Code: "x"}}
+	for i, name := range []string{"A2", "B2", "C2"} {
+		f.Submit(t, ids[i], name)
+	}
+	f.User = "checker"
+	service := &d.SqlApprListService{Approver: f.Approver()}
+	results, err := service.ApproveList(context.Background(), ids)
+	if err != nil || len(results) != 3 {
+		t.Fatalf("approve list = %v %v", results, err)
+	}
+	status := service.GetStatus()
+	tests := []struct {
+		id     string
+		status int
+		name   string
+		staged bool
+	}{
+		{"a", status.Success, "A2", false},
+		{"b", status.Error, "B", true},
+		{"c", status.Success, "C2", false},
+	}
+	for i, test := range tests {
+		if results[i].Status != test.status {
+			t.Errorf("%s: status = %d %s, want %d", test.id, results[i].Status, results[i].Error, test.status)
+		}
+		var name string
+		if err := f.DB.QueryRow("select name from items where id = ?", test.id).Scan(&name); err != nil {
+			t.Fatal(err)
+		}
+		var staged int
+		if err := f.DB.QueryRow("select count(*) from pending where id = ?", test.id+"-x").Scan(&staged); err != nil {
+			t.Fatal(err)
+		}
+		if name != test.name || (staged > 0) != test.staged {
+			t.Errorf("%s: name = %q, staged = %d, want %q and staged %t", test.id, name, staged, test.name, test.staged)
+		}
+	}
+	if count := f.Count(t, "history"); count != 2 {
+		t.Errorf("history = %d, want the 2 approved changes", count)
+	}
+}
+
+func TestApproveWithoutUserIsForbidden(t *testing.T) {
+	db := openDB(t,
+		"create table items(id text, code text, name text)",
+		"create table pending(id text, entitytype text, origin text, value text, changedby text, ts timestamp)",
+		"insert into items values('a', 'x', 'A')",
+	)
+	ctx := context.Background()
+	user := "maker"
+	getUser := func(context.Context) string { return user }
+	idNames := []string{"id", "code"}
+	modelType := reflect.TypeOf(Item{})
+	config := d.DiffConfig{ChangedBy: "changedby", Timestamp: "ts"}
+	keyBuilder := d.NewDefaultKeyBuilder()
+	submitter := d.NewSqlSubmitter(db, "items", "pending", "entitytype", modelType, idNames, config, nil, keyBuilder, getUser)
+	id := map[string]interface{}{"id": "a", "code": "x"}
+	if _, err := submitter.Submit(ctx, id, map[string]interface{}{"name": "B"}); err != nil {
+		t.Fatal(err)
+	}
+	user = ""
+	approver := d.NewSqlApprover(db, "items", "pending", "entitytype", modelType, idNames, config, nil, keyBuilder, nil, getUser)
+	approver.AllowSelfApproval = true
+	status, err := approver.Approve(ctx, id)
+	if err != nil || status != approver.Status.Forbidden {
+		t.Errorf("anonymous approve = %d %v, want Forbidden", status, err)
+	}
+}
+
+func TestApproveRefusesAStagedValueWithUnknownFields(t *testing.T) {
+	f := newFixture(t,
+		"insert into items values('a', 'x', 'A')",
+		`insert into pending(id, entitytype, origin, value, changedby) values('a-x', 'items', '{"name":"A"}', '{"name":"B","name = ''C'' --":1}', 'maker')`,
+	)
+	f.User = "checker"
+	approver := f.Approver()
+	status, err := approver.Approve(context.Background(), map[string]interface{}{"id": "a", "code": "x"})
+	if err == nil || status != approver.Status.Error {
+		t.Errorf("approve = %d %v, want Error", status, err)
+	}
+	if name := getName(t, f.DB); name != "A" {
+		t.Errorf("name = %q, want %q", name, "A")
+	}
+}
+
+func TestApproveAnUpdateWithoutValueIsAnError(t *testing.T) {
+	f := newFixture(t,
+		"alter table pending add column kind text",
+		"insert into items values('a', 'x', 'A')",
+		`insert into pending(id, entitytype, origin, value, changedby, kind) values('a-x', 'items', '{"id":"a","code":"x","name":"A"}', null, 'maker', 'update')`,
+	)
+	f.Config.Kind = "kind"
+	f.User = "checker"
+	approver := f.Approver()
+	status, err := approver.Approve(context.Background(), map[string]interface{}{"id": "a", "code": "x"})
+	if err == nil || status != approver.Status.Error {
+		t.Errorf("approve = %d %v, want Error", status, err)
+	}
+	if name := getName(t, f.DB); name != "A" {
+		t.Errorf("name = %q, want %q", name, "A")
+	}
+}
+
+func TestRebaseOfCreateAndDelete(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	submitter := f.Submitter()
+	approver := f.Approver()
+	id := map[string]interface{}{"id": "a", "code": "x"}
+
+	f.Submit(t, id, "B")
+	if _, err := f.DB.Exec("insert into items values('a', 'x', 'A')"); err != nil {
+		t.Fatal(err)
+	}
+	status, conflicts, err := approver.Rebase(ctx, id)
+	if err != nil || status != approver.Status.VersionError || !reflect.DeepEqual(conflicts, []string{"name"}) {
+		t.Errorf("rebase of a create of an existing row = %d %v %v, want a conflict on name", status, conflicts, err)
+	}
+
+	if status, err := submitter.Delete(ctx, id); err != nil || status != submitter.Status.Success {
+		t.Fatalf("delete = %d %v", status, err)
+	}
+	if _, err := f.DB.Exec("update items set name = 'C'"); err != nil {
+		t.Fatal(err)
+	}
+	if status, conflicts, err := approver.Rebase(ctx, id); err != nil || status != approver.Status.Success {
+		t.Fatalf("rebase of a delete = %d %v %v", status, conflicts, err)
+	}
+	f.User = "checker"
+	if status, err := approver.Approve(ctx, id); err != nil || status != approver.Status.Success {
+		t.Errorf("approve of the rebased delete = %d %v", status, err)
+	}
+	if count := f.Count(t, "items"); count != 0 {
+		t.Errorf("items = %d, want the row deleted", count)
+	}
+}
+
+func TestApproveComparesModelColumnsAsJson(t *testing.T) {
+	f := newFixture(t,
+		"alter table items add column active integer",
+		"insert into items values('a', 'x', 'A', 1)",
+		`insert into pending(id, entitytype, origin, value, changedby) values('a', 'items', '{"id":"a","name":"A","active":true}', '{"id":"a","name":"B","active":true}', 'maker')`,
+	)
+	f.ModelType = reflect.TypeOf(Flag{})
+	f.IdNames = []string{"id"}
+	f.User = "checker"
+	approver := f.Approver()
+	if status, err := approver.Approve(context.Background(), "a"); err != nil || status != approver.Status.Success {
+		t.Fatalf("approve = %d %v, want Success", status, err)
+	}
+	if name := getName(t, f.DB); name != "B" {
+		t.Errorf("name = %q, want %q", name, "B")
+	}
+}
+
+func TestDiffReadsTypedIdsAndNullOriginOrValue(t *testing.T) {
+	f := newFixture(t,
+		"create table numbered(id integer, entitytype text, origin text, value text, changedby text)",
+		`insert into numbered values(1, 'items', null, '{"name":"A"}', 'maker')`,
+		`insert into numbered values(2, 'items', '{"name":"B"}', null, null)`,
+		`insert into numbered values(3, 'items', '{"name":"C"}', '{"name":"D"}', 'maker')`,
+	)
+	tests := []struct {
+		id      int64
+		kind    string
+		origin  bool
+		value   bool
+		by      string
+		changes int
+	}{
+		{1, d.KindCreate, false, true, "maker", 1},
+		{2, d.KindDelete, true, false, "", 1},
+		{3, d.KindUpdate, true, true, "maker", 1},
+	}
+	reader := d.NewSqlDiffReader(f.DB, "items", "numbered", "entitytype", []string{"id"}, f.Config, f.KeyBuilder)
+	for _, test := range tests {
+		model, err := reader.Diff(context.Background(), test.id)
+		if err != nil || model == nil {
+			t.Fatalf("%d: diff = %v %v", test.id, model, err)
+		}
+		if model.Id != test.id || model.Kind != test.kind || (model.Origin != nil) != test.origin || (model.Value != nil) != test.value || model.By != test.by || len(model.Changes) != test.changes {
+			t.Errorf("%d: diff = %+v, want kind %s, origin %t, value %t, by %q and %d change", test.id, model, test.kind, test.origin, test.value, test.by, test.changes)
+		}
+	}
+
+	list, err := d.NewSqlDiffListReader(f.DB, "items", "numbered", "entitytype", []string{"id"}, f.Config, f.KeyBuilder).Diff(context.Background(), []int64{1, 2})
+	if err != nil || list == nil || len(*list) != 2 {
+		t.Fatalf("diff list = %v %v", list, err)
+	}
+	for _, model := range *list {
+		if _, ok := model.Id.(int64); !ok {
+			t.Errorf("id = %#v, want the integer of the column", model.Id)
+		}
+	}
+}
+
+func TestSqlServiceSuite(t *testing.T) {
+	difftest.RunServiceSuite(t, func(t *testing.T, idNames []string) *difftest.Backend {
+		modelType := reflect.TypeOf(User{})
+		columns := "id text, name text, version integer"
+		if len(idNames) > 1 {
+			modelType = reflect.TypeOf(CodedUser{})
+			columns = "id text, code text, name text, version integer"
+		}
+		db := openDB(t,
+			"create table users("+columns+")",
+			"create table pending(id text, entitytype text, origin text, value text, changedby text, ts timestamp)",
+			"create table history(historyid text, entitytype text, id text, origin text, value text, changedby text, approvedby text, ts timestamp)",
+		)
+		user := ""
+		getUser := func(context.Context) string { return user }
+		config := d.DiffConfig{ChangedBy: "changedby", Timestamp: "ts", Version: "version"}
+		keyBuilder := d.NewDefaultKeyBuilder()
+		historyIds := 0
+		history := d.NewSqlHistoryWriter("history", "users", idNames, d.DiffConfig{HistoryId: "historyid", ChangedBy: "changedby", ApprovedBy: "approvedby", Timestamp: "ts"}, keyBuilder, func(int) string { return "?" }, func() (string, error) {
+			historyIds++
+			return fmt.Sprint(historyIds), nil
+		})
+		where := func(id interface{}) (string, []interface{}) {
+			if keys, ok := id.(map[string]interface{}); ok {
+				return "id = ? and code = ?", []interface{}{keys["id"], keys["code"]}
+			}
+			return "id = ?", []interface{}{id}
+		}
+		return &difftest.Backend{
+			Diff:     d.NewSqlDiffReader(db, "users", "pending", "entitytype", idNames, config, keyBuilder),
+			DiffList: d.NewSqlDiffListReader(db, "users", "pending", "entitytype", idNames, config, keyBuilder),
+			Appr:     d.NewSqlApprover(db, "users", "pending", "entitytype", modelType, idNames, config, nil, keyBuilder, history, getUser),
+			ApprList: d.NewSqlApprListService(db, "users", "pending", "entitytype", modelType, idNames, config, nil, keyBuilder, history, getUser),
+			Submit:   d.NewSqlSubmitter(db, "users", "pending", "entitytype", modelType, idNames, config, nil, keyBuilder, getUser),
+			Status:   d.InitializeStatus(nil),
+			SetUser:  func(u string) { user = u },
+			Seed: func(ctx context.Context, id interface{}, record map[string]interface{}) error {
+				condition, args := where(id)
+				if _, err := db.ExecContext(ctx, "delete from users where "+condition, args...); err != nil {
+					return err
+				}
+				var names, params []string
+				var values []interface{}
+				for name, value := range record {
+					names = append(names, name)
+					params = append(params, "?")
+					values = append(values, value)
+				}
+				_, err := db.ExecContext(ctx, "insert into users("+strings.Join(names, ",")+") values ("+strings.Join(params, ",")+")", values...)
+				return err
+			},
+			Get: func(ctx context.Context, id interface{}) (map[string]interface{}, error) {
+				condition, args := where(id)
+				var name string
+				if err := db.QueryRowContext(ctx, "select name from users where "+condition, args...).Scan(&name); err != nil {
+					return nil, err
+				}
+				return map[string]interface{}{"name": name}, nil
+			},
+			History: func(ctx context.Context) ([]d.DiffModel, error) {
+				var histories []d.DiffModel
+				err := d.QueryDiffs(ctx, db, &histories, "select id, origin, value, changedby from history")
+				return histories, err
+			},
+		}
+	})
+}
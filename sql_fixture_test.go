@@ -0,0 +1,125 @@
+package diff_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"testing"
+
+	d "github.com/core-go/diff"
+	_ "modernc.org/sqlite"
+)
+
+type Item struct {
+	Id   string `json:"id" gorm:"column:id;primary_key"`
+	Code string `json:"code" gorm:"column:code;primary_key"`
+	Name string `json:"name" gorm:"column:name"`
+}
+
+var dbs int
+
+func openDB(t *testing.T, queries ...string) *sql.DB {
+	t.Helper()
+	dbs++
+	db, err := sql.Open("sqlite", fmt.Sprintf("file:%s%d?mode=memory&cache=shared", t.Name(), dbs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	for _, query := range queries {
+		if _, err := db.Exec(query); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db
+}
+
+// fixture is a sqlite database with the items table, whose id is composite, its staging and history tables, and the queries of the test.
+// The services it builds get their user from User.
+type fixture struct {
+	DB            *sql.DB
+	User          string
+	IdNames       []string
+	ModelType     reflect.Type
+	Config        d.DiffConfig
+	HistoryConfig d.DiffConfig
+	KeyBuilder    d.KeyBuilder
+	historyIds    int
+}
+
+func newFixture(t *testing.T, queries ...string) *fixture {
+	t.Helper()
+	db := openDB(t, append([]string{
+		"create table items(id text, code text, name text)",
+		"create table pending(id text, entitytype text, origin text, value text, changedby text, ts timestamp)",
+		"create table history(historyid text, entitytype text, id text, origin text, value text, changedby text, approvedby text, ts timestamp, state text)",
+	}, queries...)...)
+	return &fixture{
+		DB:            db,
+		User:          "maker",
+		IdNames:       []string{"id", "code"},
+		ModelType:     reflect.TypeOf(Item{}),
+		Config:        d.DiffConfig{ChangedBy: "changedby", Timestamp: "ts"},
+		HistoryConfig: d.DiffConfig{HistoryId: "historyid", ChangedBy: "changedby", ApprovedBy: "approvedby", Timestamp: "ts", State: "state"},
+		KeyBuilder:    d.NewDefaultKeyBuilder(),
+	}
+}
+
+func (f *fixture) GetUser(context.Context) string {
+	return f.User
+}
+
+func (f *fixture) Submitter() *d.SqlSubmitter {
+	submitter := d.NewSqlSubmitter(f.DB, "items", "pending", "entitytype", f.ModelType, f.IdNames, f.Config, nil, f.KeyBuilder, f.GetUser)
+	submitter.History = f.HistoryReader()
+	return submitter
+}
+
+func (f *fixture) Approver() *d.SqlApprover {
+	return d.NewSqlApprover(f.DB, "items", "pending", "entitytype", f.ModelType, f.IdNames, f.Config, nil, f.KeyBuilder, f.HistoryWriter(), f.GetUser)
+}
+
+func (f *fixture) DiffReader() *d.SqlDiffReader {
+	return d.NewSqlDiffReader(f.DB, "items", "pending", "entitytype", f.IdNames, f.Config, f.KeyBuilder)
+}
+
+// HistoryWriter writes the history ids h1, h2...
+func (f *fixture) HistoryWriter() *d.SqlHistoryWriter {
+	return d.NewSqlHistoryWriter("history", "items", f.IdNames, f.HistoryConfig, f.KeyBuilder, func(int) string { return "?" }, func() (string, error) {
+		f.historyIds++
+		return fmt.Sprintf("h%d", f.historyIds), nil
+	})
+}
+
+func (f *fixture) HistoryReader() *d.SqlHistoryReader {
+	return d.NewSqlHistoryReader(f.DB, "history", "items", "entitytype", f.IdNames, f.HistoryConfig, f.KeyBuilder)
+}
+
+// Submit stages a change of the name of the item by User.
+func (f *fixture) Submit(t *testing.T, id interface{}, name string) {
+	t.Helper()
+	submitter := f.Submitter()
+	if status, err := submitter.Submit(context.Background(), id, map[string]interface{}{"name": name}); err != nil || status != submitter.Status.Success {
+		t.Fatalf("submit = %d %v", status, err)
+	}
+}
+
+func (f *fixture) Count(t *testing.T, table string) int {
+	t.Helper()
+	var count int
+	if err := f.DB.QueryRow("select count(*) from " + table).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+func getName(t *testing.T, db *sql.DB) string {
+	t.Helper()
+	var name string
+	if err := db.QueryRow("select name from items").Scan(&name); err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
@@ -0,0 +1,303 @@
+package diff
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type SqlHistoryReader struct {
+	DB         *sql.DB
+	Table      string
+	Entity     string
+	EntityType string
+	IdNames    []string
+	Config     DiffConfig
+	KeyBuilder KeyBuilder
+	BuildParam func(int) string
+	Driver     string
+}
+
+func NewSqlHistoryReader(db *sql.DB, table string, entity string, entityType string, idNames []string, config DiffConfig, keyBuilder KeyBuilder, options ...func(int) string) *SqlHistoryReader {
+	driver := getDriver(db)
+	var buildParam func(int) string
+	if len(options) > 0 && options[0] != nil {
+		buildParam = options[0]
+	} else {
+		buildParam = getBuild(db)
+	}
+	return &SqlHistoryReader{DB: db, Table: table, Entity: entity, EntityType: entityType, IdNames: idNames, Config: getDefaultConfig(config), KeyBuilder: keyBuilder, BuildParam: buildParam, Driver: driver}
+}
+
+func (r SqlHistoryReader) Load(ctx context.Context, historyId string) (*DiffModel, error) {
+	if len(r.Config.HistoryId) == 0 {
+		return nil, fmt.Errorf("history id column is not configured for %s", r.Table)
+	}
+	columns, scan := r.buildColumns()
+	query := fmt.Sprintf("select %s from %s where %s = %s and %s = %s", strings.Join(columns, ","), r.Table,
+		r.Config.HistoryId, r.BuildParam(1),
+		r.EntityType, r.BuildParam(2))
+	rows, err := r.DB.QueryContext(ctx, query, historyId, r.Entity)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		return nil, rows.Err()
+	}
+	return scan(rows)
+}
+
+func (r SqlHistoryReader) Search(ctx context.Context, filter HistoryFilter) ([]DiffModel, int64, error) {
+	where, args, err := r.buildFilter(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	var total int64
+	err = r.DB.QueryRowContext(ctx, fmt.Sprintf("select count(*) from %s where %s", r.Table, where), args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+	orderBy := r.Config.Id
+	if len(r.Config.Timestamp) > 0 {
+		orderBy = r.Config.Timestamp + " desc"
+	}
+	list, err := r.query(ctx, where, args, orderBy, filter.Page, filter.Limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	return list, total, nil
+}
+
+// Snapshot rebuilds the state of an entity at the given time: the value of the last change made until then or, if there is none, the origin of the first change made after.
+func (r SqlHistoryReader) Snapshot(ctx context.Context, id interface{}, at time.Time) (interface{}, error) {
+	if len(r.Config.Timestamp) == 0 {
+		return nil, fmt.Errorf("timestamp column is not configured for %s", r.Table)
+	}
+	where, args, err := r.buildFilter(HistoryFilter{Id: id, To: &at})
+	if err != nil {
+		return nil, err
+	}
+	list, err := r.query(ctx, where, args, r.Config.Timestamp+" desc", 1, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(list) > 0 {
+		return list[0].Value, nil
+	}
+	where, args, err = r.buildFilter(HistoryFilter{Id: id, From: &at})
+	if err != nil {
+		return nil, err
+	}
+	list, err = r.query(ctx, where, args, r.Config.Timestamp, 1, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(list) > 0 {
+		return list[0].Origin, nil
+	}
+	return nil, nil
+}
+
+func (r SqlHistoryReader) buildFilter(filter HistoryFilter) (string, []interface{}, error) {
+	var conditions []string
+	var args []interface{}
+	conditions = append(conditions, r.EntityType+" = "+r.BuildParam(1))
+	args = append(args, r.Entity)
+	if filter.Id != nil {
+		key, err := r.buildKey(filter.Id)
+		if err != nil {
+			return "", nil, err
+		}
+		conditions = append(conditions, r.Config.Id+" = "+r.BuildParam(len(args)+1))
+		args = append(args, key)
+	}
+	if len(filter.By) > 0 && len(r.Config.ChangedBy) > 0 {
+		conditions = append(conditions, r.Config.ChangedBy+" = "+r.BuildParam(len(args)+1))
+		args = append(args, filter.By)
+	}
+	if len(filter.ApprovedBy) > 0 && len(r.Config.ApprovedBy) > 0 {
+		conditions = append(conditions, r.Config.ApprovedBy+" = "+r.BuildParam(len(args)+1))
+		args = append(args, filter.ApprovedBy)
+	}
+	if filter.From != nil && len(r.Config.Timestamp) > 0 {
+		conditions = append(conditions, r.Config.Timestamp+" >= "+r.BuildParam(len(args)+1))
+		args = append(args, *filter.From)
+	}
+	if filter.To != nil && len(r.Config.Timestamp) > 0 {
+		conditions = append(conditions, r.Config.Timestamp+" <= "+r.BuildParam(len(args)+1))
+		args = append(args, *filter.To)
+	}
+	// rejected changes are kept in history for audit but were never applied, so they are listed only on demand
+	if len(r.Config.State) > 0 {
+		if len(filter.State) == 0 || filter.State == StateApproved {
+			conditions = append(conditions, "("+r.Config.State+" is null or "+r.Config.State+" = "+r.BuildParam(len(args)+1)+")")
+			args = append(args, StateApproved)
+		} else {
+			conditions = append(conditions, r.Config.State+" = "+r.BuildParam(len(args)+1))
+			args = append(args, filter.State)
+		}
+	}
+	return strings.Join(conditions, " and "), args, nil
+}
+
+func (r SqlHistoryReader) query(ctx context.Context, where string, args []interface{}, orderBy string, page int64, limit int64) ([]DiffModel, error) {
+	columns, scan := r.buildColumns()
+	query := fmt.Sprintf("select %s from %s where %s order by %s", strings.Join(columns, ","), r.Table, where, orderBy)
+	query = query + buildPaging(r.Driver, page, limit)
+	rows, err := r.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	list := make([]DiffModel, 0)
+	for rows.Next() {
+		result, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r SqlHistoryReader) buildKey(id interface{}) (string, error) {
+	if len(r.IdNames) == 1 {
+		return fmt.Sprint(id), nil
+	}
+	key, _, err := buildKeys(r.KeyBuilder, r.IdNames, id)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprint(key), nil
+}
+
+func (r SqlHistoryReader) buildColumns() ([]string, func(*sql.Rows) (*DiffModel, error)) {
+	columns := []string{r.Config.Id, r.Config.Origin, r.Config.Value}
+	if len(r.Config.ChangedBy) > 0 {
+		columns = append(columns, r.Config.ChangedBy)
+	}
+	if len(r.Config.ApprovedBy) > 0 {
+		columns = append(columns, r.Config.ApprovedBy)
+	}
+	if len(r.Config.Timestamp) > 0 {
+		columns = append(columns, r.Config.Timestamp)
+	}
+	if len(r.Config.State) > 0 {
+		columns = append(columns, r.Config.State)
+	}
+	if len(r.Config.Reason) > 0 {
+		columns = append(columns, r.Config.Reason)
+	}
+	if len(r.Config.Comments) > 0 {
+		columns = append(columns, r.Config.Comments)
+	}
+	scan := func(rows *sql.Rows) (*DiffModel, error) {
+		var id, origin, value, changedBy, approvedBy, state, reason, comments sql.NullString
+		var timestamp sql.NullTime
+		dest := []interface{}{&id, &origin, &value}
+		if len(r.Config.ChangedBy) > 0 {
+			dest = append(dest, &changedBy)
+		}
+		if len(r.Config.ApprovedBy) > 0 {
+			dest = append(dest, &approvedBy)
+		}
+		if len(r.Config.Timestamp) > 0 {
+			dest = append(dest, &timestamp)
+		}
+		if len(r.Config.State) > 0 {
+			dest = append(dest, &state)
+		}
+		if len(r.Config.Reason) > 0 {
+			dest = append(dest, &reason)
+		}
+		if len(r.Config.Comments) > 0 {
+			dest = append(dest, &comments)
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		result := DiffModel{Id: id.String, By: changedBy.String, ApprovedBy: approvedBy.String, State: state.String, Reason: reason.String}
+		if comments.Valid && len(comments.String) > 0 {
+			if err := json.Unmarshal([]byte(comments.String), &result.Comments); err != nil {
+				return nil, err
+			}
+		}
+		if origin.Valid && len(origin.String) > 0 {
+			o, err := decodeJsonObject(origin.String)
+			if err != nil {
+				return nil, err
+			}
+			result.Origin = o
+		}
+		if value.Valid && len(value.String) > 0 {
+			v, err := decodeJsonObject(value.String)
+			if err != nil {
+				return nil, err
+			}
+			result.Value = v
+		}
+		if timestamp.Valid {
+			t := timestamp.Time
+			result.Timestamp = &t
+		}
+		return &result, nil
+	}
+	return columns, scan
+}
+
+func buildPaging(driver string, page int64, limit int64) string {
+	if limit <= 0 {
+		return ""
+	}
+	offset := int64(0)
+	if page > 1 {
+		offset = (page - 1) * limit
+	}
+	if driver == DriverOracle || driver == DriverMssql {
+		return fmt.Sprintf(" offset %d rows fetch next %d rows only", offset, limit)
+	}
+	return fmt.Sprintf(" limit %d offset %d", limit, offset)
+}
+
+// getId restores the id of an entity from its stored key; a composite id is read from the first of values, the origin or the value of the change, which holds all its fields, since the key cannot be split back.
+func getId(key interface{}, idNames []string, values ...interface{}) (interface{}, error) {
+	if len(idNames) <= 1 {
+		return key, nil
+	}
+	for _, value := range values {
+		if p, ok := value.(*string); ok {
+			if p == nil {
+				continue
+			}
+			value = *p
+		}
+		if isNilJson(value) {
+			continue
+		}
+		m, ok := toJsonValue(value).(map[string]interface{})
+		if !ok {
+			var err error
+			m, err = decodeJsonObject(toJsonString(value))
+			if err != nil {
+				return nil, err
+			}
+		}
+		keyMap := make(map[string]interface{})
+		for _, name := range idNames {
+			if v, ok := m[name]; ok && v != nil {
+				keyMap[name] = v
+			}
+		}
+		if len(keyMap) == len(idNames) {
+			return keyMap, nil
+		}
+	}
+	return nil, fmt.Errorf("cannot restore the id of %v", key)
+}
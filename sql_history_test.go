@@ -0,0 +1,43 @@
+package diff_test
+
+import (
+	"context"
+	"testing"
+
+	d "github.com/core-go/diff"
+)
+
+func TestHistoryListsOnlyApprovedChangesAndRevertRefusesRejected(t *testing.T) {
+	f := newFixture(t, "insert into items values('a', 'x', 'A')")
+	ctx := context.Background()
+	reader := f.HistoryReader()
+	submitter := f.Submitter()
+	approver := f.Approver()
+	approver.KeepRejected = true
+	id := map[string]interface{}{"id": "a", "code": "x"}
+
+	for _, approve := range []bool{true, false} {
+		f.User = "maker"
+		f.Submit(t, id, "B")
+		f.User = "checker"
+		exec := approver.Reject
+		if approve {
+			exec = approver.Approve
+		}
+		if status, err := exec(ctx, id); err != nil || status != approver.Status.Success {
+			t.Fatalf("review = %d %v", status, err)
+		}
+	}
+	list, total, err := reader.Search(ctx, d.HistoryFilter{Id: id})
+	if err != nil || total != 1 || len(list) != 1 || list[0].State != d.StateApproved {
+		t.Errorf("search = %v %d %v, want the approved change only", list, total, err)
+	}
+	list, total, err = reader.Search(ctx, d.HistoryFilter{Id: id, State: d.StateRejected})
+	if err != nil || total != 1 || len(list) != 1 || list[0].State != d.StateRejected {
+		t.Errorf("search rejected = %v %d %v, want the rejected change", list, total, err)
+	}
+	f.User = "maker"
+	if status, err := submitter.Revert(ctx, "h2"); err != nil || status != submitter.Status.Forbidden {
+		t.Errorf("revert of a rejected change = %d %v, want Forbidden", status, err)
+	}
+}
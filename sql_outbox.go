@@ -0,0 +1,275 @@
+package diff
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type OutboxConfig struct {
+	Id         string `yaml:"id" mapstructure:"id" json:"id,omitempty" gorm:"column:id" bson:"_id,omitempty" dynamodbav:"id,omitempty" firestore:"id,omitempty"`
+	Type       string `yaml:"type" mapstructure:"type" json:"type,omitempty" gorm:"column:type" bson:"type,omitempty" dynamodbav:"type,omitempty" firestore:"type,omitempty"`
+	EntityType string `yaml:"entity_type" mapstructure:"entity_type" json:"entityType,omitempty" gorm:"column:entitytype" bson:"entityType,omitempty" dynamodbav:"entityType,omitempty" firestore:"entityType,omitempty"`
+	EntityId   string `yaml:"entity_id" mapstructure:"entity_id" json:"entityId,omitempty" gorm:"column:entityid" bson:"entityId,omitempty" dynamodbav:"entityId,omitempty" firestore:"entityId,omitempty"`
+	Payload    string `yaml:"payload" mapstructure:"payload" json:"payload,omitempty" gorm:"column:payload" bson:"payload,omitempty" dynamodbav:"payload,omitempty" firestore:"payload,omitempty"`
+	Timestamp  string `yaml:"timestamp" mapstructure:"timestamp" json:"timestamp,omitempty" gorm:"column:timestamp" bson:"timestamp,omitempty" dynamodbav:"timestamp,omitempty" firestore:"timestamp,omitempty"`
+	Published  string `yaml:"published" mapstructure:"published" json:"published,omitempty" gorm:"column:published" bson:"published,omitempty" dynamodbav:"published,omitempty" firestore:"published,omitempty"`
+	Locked     string `yaml:"locked" mapstructure:"locked" json:"locked,omitempty" gorm:"column:locked" bson:"locked,omitempty" dynamodbav:"locked,omitempty" firestore:"locked,omitempty"`
+}
+
+// SqlOutbox writes events to the outbox table; the event is stored as json in the payload column.
+type SqlOutbox struct {
+	Table      string
+	Config     OutboxConfig
+	BuildParam func(int) string
+	Generate   func() (string, error)
+}
+
+// SqlOutboxRelay drains the outbox table to Sink, oldest events first. When Config.Published is set, published events are marked with the publish time, otherwise they are deleted.
+// Without Config.Locked, only one relay may drain the table. When Config.Locked is set to a timestamp column of the outbox table, which an existing table must be altered to add,
+// several relays may drain it: an event is locked by one relay until Lease is over, which is the time the relay has to publish it.
+type SqlOutboxRelay struct {
+	DB         *sql.DB
+	Table      string
+	Config     OutboxConfig
+	Sink       EventSink
+	Limit      int64
+	Lease      time.Duration
+	Log        func(context.Context, string)
+	BuildParam func(int) string
+	Driver     string
+}
+
+func NewSqlOutbox(table string, config OutboxConfig, buildParam func(int) string, generate func() (string, error)) *SqlOutbox {
+	return &SqlOutbox{Table: table, Config: getDefaultOutboxConfig(config), BuildParam: buildParam, Generate: generate}
+}
+
+func NewSqlOutboxRelay(db *sql.DB, table string, config OutboxConfig, sink EventSink, limit int64, logError func(context.Context, string), options ...func(int) string) *SqlOutboxRelay {
+	var buildParam func(int) string
+	if len(options) > 0 && options[0] != nil {
+		buildParam = options[0]
+	} else {
+		buildParam = getBuild(db)
+	}
+	if limit <= 0 {
+		limit = 100
+	}
+	return &SqlOutboxRelay{DB: db, Table: table, Config: getDefaultOutboxConfig(config), Sink: sink, Limit: limit, Lease: 5 * time.Minute, Log: logError, BuildParam: buildParam, Driver: getDriver(db)}
+}
+
+func getDefaultOutboxConfig(config OutboxConfig) OutboxConfig {
+	if config.Id == "" {
+		config.Id = "id"
+	}
+	if config.Type == "" {
+		config.Type = "type"
+	}
+	if config.Payload == "" {
+		config.Payload = "payload"
+	}
+	if config.Timestamp == "" {
+		config.Timestamp = "timestamp"
+	}
+	return config
+}
+
+func (o SqlOutbox) Write(ctx context.Context, tx *sql.Tx, event Event) error {
+	if len(event.Id) == 0 {
+		id, err := o.generate()
+		if err != nil {
+			return err
+		}
+		event.Id = id
+	}
+	if event.Timestamp == nil {
+		now := time.Now()
+		event.Timestamp = &now
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	columns := []string{o.Config.Id, o.Config.Type, o.Config.Payload, o.Config.Timestamp}
+	values := []interface{}{event.Id, event.Type, string(payload), *event.Timestamp}
+	if len(o.Config.EntityType) > 0 {
+		columns = append(columns, o.Config.EntityType)
+		values = append(values, event.EntityType)
+	}
+	if len(o.Config.EntityId) > 0 {
+		columns = append(columns, o.Config.EntityId)
+		values = append(values, fmt.Sprint(event.EntityId))
+	}
+	query := fmt.Sprintf("insert into %s(%s) values (%s)", o.Table, strings.Join(columns, ","), buildParameters(len(columns), o.BuildParam))
+	_, err = tx.ExecContext(ctx, query, values...)
+	return err
+}
+
+func (o SqlOutbox) generate() (string, error) {
+	if o.Generate != nil {
+		return o.Generate()
+	}
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Relay publishes up to Limit pending events and returns how many were published; it stops at the first event the sink fails to publish.
+// Only a single relay publishes the events in order: relays publish the events they locked at the same time, and an event whose lease is over may be published after a newer one.
+// The events are locked in a short transaction and published out of it, so that a slow sink holds no transaction.
+func (r SqlOutboxRelay) Relay(ctx context.Context) (int, error) {
+	ids, events, err := r.lock(ctx)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for i, event := range events {
+		if err = r.Sink.Publish(ctx, event); err != nil {
+			r.unlock(ctx, ids[i:])
+			return count, err
+		}
+		if err = r.markPublished(ctx, ids[i]); err != nil {
+			r.unlock(ctx, ids[i+1:])
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// lock selects the pending events which are not locked by another relay, and locks them until the lease is over.
+func (r SqlOutboxRelay) lock(ctx context.Context) ([]string, []Event, error) {
+	now := time.Now()
+	query := fmt.Sprintf("select %s, %s from %s", r.Config.Id, r.Config.Payload, r.Table)
+	var args []interface{}
+	if where := r.buildUnlocked(1); len(where) > 0 {
+		query = query + " where " + where
+	}
+	if len(r.Config.Locked) > 0 {
+		args = append(args, now)
+	}
+	query = query + fmt.Sprintf(" order by %s, %s", r.Config.Timestamp, r.Config.Id)
+	query = query + buildPaging(r.Driver, 1, r.Limit)
+	rows, err := r.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	ids := make([]string, 0)
+	events := make([]Event, 0)
+	for rows.Next() {
+		var id, payload string
+		if err := rows.Scan(&id, &payload); err != nil {
+			rows.Close()
+			return nil, nil, err
+		}
+		var event Event
+		if err := json.Unmarshal([]byte(payload), &event); err != nil {
+			rows.Close()
+			return nil, nil, err
+		}
+		ids = append(ids, id)
+		events = append(events, event)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	if len(ids) == 0 || len(r.Config.Locked) == 0 {
+		return ids, events, nil
+	}
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+	// the condition is checked again, so that an event listed by two relays is locked by one of them only
+	query = fmt.Sprintf("update %s set %s = %s where %s = %s and %s", r.Table, r.Config.Locked, r.BuildParam(1), r.Config.Id, r.BuildParam(2), r.buildUnlocked(3))
+	lockedIds := make([]string, 0)
+	lockedEvents := make([]Event, 0)
+	for i, id := range ids {
+		res, err := tx.ExecContext(ctx, query, now.Add(r.Lease), id, now)
+		if err != nil {
+			return nil, nil, err
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return nil, nil, err
+		}
+		if affected > 0 {
+			lockedIds = append(lockedIds, id)
+			lockedEvents = append(lockedEvents, events[i])
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+	return lockedIds, lockedEvents, nil
+}
+
+// buildUnlocked returns the condition of the pending events which are not locked, where the parameter i is the current time; it is empty when all events are pending.
+func (r SqlOutboxRelay) buildUnlocked(i int) string {
+	conditions := make([]string, 0)
+	if len(r.Config.Published) > 0 {
+		conditions = append(conditions, r.Config.Published+" is null")
+	}
+	if len(r.Config.Locked) > 0 {
+		conditions = append(conditions, fmt.Sprintf("(%s is null or %s < %s)", r.Config.Locked, r.Config.Locked, r.BuildParam(i)))
+	}
+	return strings.Join(conditions, " and ")
+}
+
+func (r SqlOutboxRelay) markPublished(ctx context.Context, id string) error {
+	var err error
+	if len(r.Config.Published) > 0 {
+		query := fmt.Sprintf("update %s set %s = %s where %s = %s", r.Table, r.Config.Published, r.BuildParam(1), r.Config.Id, r.BuildParam(2))
+		_, err = r.DB.ExecContext(ctx, query, time.Now(), id)
+	} else {
+		query := fmt.Sprintf("delete from %s where %s = %s", r.Table, r.Config.Id, r.BuildParam(1))
+		_, err = r.DB.ExecContext(ctx, query, id)
+	}
+	return err
+}
+
+// unlock releases the events which were not published, so that the next relay does not wait for the lease to be over.
+func (r SqlOutboxRelay) unlock(ctx context.Context, ids []string) {
+	if len(r.Config.Locked) == 0 {
+		return
+	}
+	query := fmt.Sprintf("update %s set %s = null where %s = %s", r.Table, r.Config.Locked, r.Config.Id, r.BuildParam(1))
+	for _, id := range ids {
+		if _, err := r.DB.ExecContext(ctx, query, id); err != nil {
+			if r.Log != nil {
+				r.Log(ctx, err.Error())
+			}
+			return
+		}
+	}
+}
+
+// Run relays events every interval until ctx is done; errors are reported to Log.
+func (r SqlOutboxRelay) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		for {
+			count, err := r.Relay(ctx)
+			if err != nil && r.Log != nil {
+				r.Log(ctx, err.Error())
+			}
+			if err != nil || int64(count) < r.Limit {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package diff_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	d "github.com/core-go/diff"
+)
+
+func TestOutboxRelaySkipsLockedEventsAndUnlocksUnpublished(t *testing.T) {
+	db := openDB(t,
+		"create table outbox(id text, type text, payload text, timestamp timestamp, published timestamp, locked timestamp)",
+		"insert into outbox(id, type, payload, timestamp) values ('1', 't', '{\"id\":\"1\"}', '2026-01-01 00:00:01')",
+		"insert into outbox(id, type, payload, timestamp) values ('2', 't', '{\"id\":\"2\"}', '2026-01-01 00:00:02')",
+		"insert into outbox(id, type, payload, timestamp) values ('3', 't', '{\"id\":\"3\"}', '2026-01-01 00:00:03')",
+	)
+	ctx := context.Background()
+	if _, err := db.Exec("update outbox set locked = ? where id = '1'", time.Now().Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	var published []string
+	fail := "3"
+	sink := d.EventSinkFunc(func(ctx context.Context, event d.Event) error {
+		if event.Id == fail {
+			return errors.New("unavailable")
+		}
+		published = append(published, event.Id)
+		return nil
+	})
+	relay := d.NewSqlOutboxRelay(db, "outbox", d.OutboxConfig{Published: "published", Locked: "locked"}, sink, 10, nil, func(int) string { return "?" })
+	count, err := relay.Relay(ctx)
+	if err == nil || count != 1 || len(published) != 1 || published[0] != "2" {
+		t.Fatalf("relay = %d %v, published %v; want only the unlocked event 2", count, err, published)
+	}
+	var locked int
+	if err := db.QueryRow("select count(*) from outbox where locked is not null and published is null").Scan(&locked); err != nil {
+		t.Fatal(err)
+	}
+	if locked != 1 {
+		t.Errorf("locked events = %d, want only the event locked by another relay", locked)
+	}
+	fail = ""
+	count, err = relay.Relay(ctx)
+	if err != nil || count != 1 || published[1] != "3" {
+		t.Errorf("relay again = %d %v, published %v", count, err, published)
+	}
+}
+
+func TestOutboxRelayWithoutLockedColumn(t *testing.T) {
+	db := openDB(t,
+		"create table outbox(id text, type text, payload text, timestamp timestamp)",
+		"insert into outbox(id, type, payload, timestamp) values ('1', 't', '{\"id\":\"1\"}', '2026-01-01 00:00:01')",
+		"insert into outbox(id, type, payload, timestamp) values ('2', 't', '{\"id\":\"2\"}', '2026-01-01 00:00:02')",
+	)
+	var published []string
+	sink := d.EventSinkFunc(func(ctx context.Context, event d.Event) error {
+		published = append(published, event.Id)
+		return nil
+	})
+	relay := d.NewSqlOutboxRelay(db, "outbox", d.OutboxConfig{}, sink, 10, nil, func(int) string { return "?" })
+	count, err := relay.Relay(context.Background())
+	if err != nil || count != 2 || len(published) != 2 || published[0] != "1" || published[1] != "2" {
+		t.Fatalf("relay = %d %v, published %v", count, err, published)
+	}
+	var pending int
+	if err := db.QueryRow("select count(*) from outbox").Scan(&pending); err != nil {
+		t.Fatal(err)
+	}
+	if pending != 0 {
+		t.Errorf("pending events = %d, want the published events deleted", pending)
+	}
+}
@@ -0,0 +1,421 @@
+package diff
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type SqlSubmitter struct {
+	DB         *sql.DB
+	Table      string
+	Entity     string
+	EntityType string
+	IdNames    []string
+	Config     DiffConfig
+	Status     StatusConfig
+	KeyBuilder KeyBuilder
+	GetUser    func(context.Context) string
+	BuildParam func(int) string
+	Driver     string
+	Columns    map[string]string
+	History    HistoryReader
+	// ClearApprovals, when set, deletes the approvals of a workflow when a change is submitted again; set it to SqlApprovalWorkflow.ClearApprovals
+	ClearApprovals func(ctx context.Context, tx *sql.Tx, key interface{}) error
+	types          map[string]reflect.Type
+}
+
+func NewSqlSubmitter(db *sql.DB, table string, entity string, entityType string, modelType reflect.Type, idNames []string, config DiffConfig, status *StatusConfig, keyBuilder KeyBuilder, getUser func(context.Context) string, options ...func(int) string) *SqlSubmitter {
+	driver := getDriver(db)
+	var buildParam func(int) string
+	if len(options) > 0 && options[0] != nil {
+		buildParam = options[0]
+	} else {
+		buildParam = getBuild(db)
+	}
+	columns := getJsonColumns(modelType)
+	return &SqlSubmitter{DB: db, Table: table, Entity: entity, EntityType: entityType, IdNames: idNames, Config: getDefaultConfig(config), Status: InitializeStatus(status), KeyBuilder: keyBuilder, GetUser: getUser, BuildParam: buildParam, Driver: driver, Columns: columns, types: getJsonTypes(modelType)}
+}
+
+func (r SqlSubmitter) GetStatus() StatusConfig {
+	return r.Status
+}
+
+// Submit stages a patch of the live record; when the record does not exist, the patch is staged as a create change.
+func (r SqlSubmitter) Submit(ctx context.Context, id interface{}, patch map[string]interface{}) (int, error) {
+	for name := range patch {
+		if _, ok := r.Columns[name]; !ok {
+			return r.Status.Error, fmt.Errorf("%s is not a field of %s", name, r.Table)
+		}
+	}
+	return r.submit(ctx, id, func(origin map[string]interface{}, keys map[string]interface{}) (map[string]interface{}, bool) {
+		value, _ := MergePatch(origin, patch).(map[string]interface{})
+		if origin == nil {
+			for k, v := range keys {
+				value[k] = v
+			}
+		}
+		return value, true
+	})
+}
+
+// Delete stages the removal of the live record.
+func (r SqlSubmitter) Delete(ctx context.Context, id interface{}) (int, error) {
+	return r.submit(ctx, id, func(origin map[string]interface{}, keys map[string]interface{}) (map[string]interface{}, bool) {
+		return nil, origin != nil
+	})
+}
+
+func (r SqlSubmitter) submit(ctx context.Context, id interface{}, build func(map[string]interface{}, map[string]interface{}) (map[string]interface{}, bool)) (int, error) {
+	key, keys, err := buildKeys(r.KeyBuilder, r.IdNames, id)
+	if err != nil {
+		return r.Status.Error, err
+	}
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return r.Status.Error, err
+	}
+	origin, err := getCurrent(ctx, tx, r.Table, r.Columns, r.types, r.IdNames, keys, r.BuildParam)
+	if err != nil {
+		tx.Rollback()
+		return r.Status.Error, err
+	}
+	value, ok := build(origin, keys)
+	if !ok {
+		tx.Rollback()
+		return r.Status.NotFound, nil
+	}
+	by := ""
+	if r.GetUser != nil {
+		by = r.GetUser(ctx)
+	}
+	ok, err = r.isMaker(ctx, tx, key, by)
+	if err != nil {
+		tx.Rollback()
+		return r.Status.Error, err
+	}
+	if !ok {
+		tx.Rollback()
+		return r.Status.Forbidden, nil
+	}
+	err = r.stage(ctx, tx, key, origin, value, by)
+	if err != nil {
+		tx.Rollback()
+		return r.Status.Error, err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return r.Status.Error, err
+	}
+	return r.Status.Success, nil
+}
+
+// Revert stages the inverse of an approved change found in history; the reverting change is approved like any other change.
+func (r SqlSubmitter) Revert(ctx context.Context, historyId string) (int, error) {
+	if r.History == nil {
+		return r.Status.Error, errors.New("history reader is required to revert a change")
+	}
+	entry, err := r.History.Load(ctx, historyId)
+	if err != nil {
+		return r.Status.Error, err
+	}
+	if entry == nil {
+		return r.Status.NotFound, nil
+	}
+	// a rejected change was never applied, so there is nothing to revert
+	if len(entry.State) > 0 && entry.State != StateApproved {
+		return r.Status.Forbidden, nil
+	}
+	id, err := getId(entry.Id, r.IdNames, entry.Origin, entry.Value)
+	if err != nil {
+		return r.Status.Error, err
+	}
+	key, keys, err := buildKeys(r.KeyBuilder, r.IdNames, id)
+	if err != nil {
+		return r.Status.Error, err
+	}
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return r.Status.Error, err
+	}
+	origin, err := getCurrent(ctx, tx, r.Table, r.Columns, r.types, r.IdNames, keys, r.BuildParam)
+	if err != nil {
+		tx.Rollback()
+		return r.Status.Error, err
+	}
+	if origin == nil && isNilJson(entry.Origin) {
+		tx.Rollback()
+		return r.Status.NotFound, nil
+	}
+	var value interface{}
+	if !isNilJson(entry.Origin) {
+		value = entry.Origin
+	}
+	by := ""
+	if r.GetUser != nil {
+		by = r.GetUser(ctx)
+	}
+	ok, err := r.isMaker(ctx, tx, key, by)
+	if err != nil {
+		tx.Rollback()
+		return r.Status.Error, err
+	}
+	if !ok {
+		tx.Rollback()
+		return r.Status.Forbidden, nil
+	}
+	err = r.stage(ctx, tx, key, origin, value, by)
+	if err != nil {
+		tx.Rollback()
+		return r.Status.Error, err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return r.Status.Error, err
+	}
+	return r.Status.Success, nil
+}
+
+// isMaker returns whether by may stage a change over the staged one, pending or sent back: only its maker may submit it again.
+func (r SqlSubmitter) isMaker(ctx context.Context, tx *sql.Tx, key interface{}, by string) (bool, error) {
+	byColumn := getByColumn(r.Config)
+	if len(byColumn) == 0 {
+		return true, nil
+	}
+	query := fmt.Sprintf("select %s from %s where %s = %s and %s = %s", byColumn, r.Entity,
+		r.Config.Id, r.BuildParam(1),
+		r.EntityType, r.BuildParam(2))
+	var maker sql.NullString
+	err := tx.QueryRowContext(ctx, query, key, r.Table).Scan(&maker)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return true, nil
+		}
+		return false, err
+	}
+	return !maker.Valid || maker.String == by, nil
+}
+
+func (r SqlSubmitter) stage(ctx context.Context, tx *sql.Tx, key interface{}, origin interface{}, value interface{}, by string) error {
+	var revisions []Revision
+	if len(r.Config.Revisions) > 0 {
+		var err error
+		revisions, err = r.getRevisions(ctx, tx, key)
+		if err != nil {
+			return err
+		}
+	}
+	query := fmt.Sprintf("delete from %s where %s = %s and %s = %s", r.Entity,
+		r.Config.Id, r.BuildParam(1),
+		r.EntityType, r.BuildParam(2))
+	_, err := tx.ExecContext(ctx, query, key, r.Table)
+	if err != nil {
+		return err
+	}
+	if r.ClearApprovals != nil {
+		err = r.ClearApprovals(ctx, tx, key)
+		if err != nil {
+			return err
+		}
+	}
+	kind := GetKind("", origin, value)
+	o, err := toNullJson(origin)
+	if err != nil {
+		return err
+	}
+	v, err := toNullJson(value)
+	if err != nil {
+		return err
+	}
+	columns := []string{r.Config.Id, r.EntityType, r.Config.Origin, r.Config.Value}
+	values := []interface{}{key, r.Table, o, v}
+	if len(r.Config.Kind) > 0 {
+		columns = append(columns, r.Config.Kind)
+		values = append(values, kind)
+	}
+	if byColumn := getByColumn(r.Config); len(byColumn) > 0 {
+		columns = append(columns, byColumn)
+		values = append(values, by)
+	}
+	if len(r.Config.Timestamp) > 0 {
+		columns = append(columns, r.Config.Timestamp)
+		values = append(values, time.Now())
+	}
+	if len(r.Config.State) > 0 {
+		columns = append(columns, r.Config.State)
+		values = append(values, StatePending)
+	}
+	if len(r.Config.Revisions) > 0 {
+		var rv interface{}
+		if len(revisions) > 0 {
+			b, err := json.Marshal(revisions)
+			if err != nil {
+				return err
+			}
+			rv = string(b)
+		}
+		columns = append(columns, r.Config.Revisions)
+		values = append(values, rv)
+	}
+	query = fmt.Sprintf("insert into %s(%s) values (%s)", r.Entity, strings.Join(columns, ","), buildParameters(len(columns), r.BuildParam))
+	_, err = tx.ExecContext(ctx, query, values...)
+	return err
+}
+
+// getRevisions returns the revisions of the staged change with its current submission appended, so that a resubmission keeps the trail of the previous ones.
+func (r SqlSubmitter) getRevisions(ctx context.Context, tx *sql.Tx, key interface{}) ([]Revision, error) {
+	columns := []string{r.Config.Value, r.Config.Revisions}
+	byColumn := getByColumn(r.Config)
+	if len(byColumn) > 0 {
+		columns = append(columns, byColumn)
+	}
+	if len(r.Config.Timestamp) > 0 {
+		columns = append(columns, r.Config.Timestamp)
+	}
+	query := fmt.Sprintf("select %s from %s where %s = %s and %s = %s", strings.Join(columns, ","), r.Entity,
+		r.Config.Id, r.BuildParam(1),
+		r.EntityType, r.BuildParam(2))
+	var value, revisions, by sql.NullString
+	var timestamp sql.NullTime
+	dest := []interface{}{&value, &revisions}
+	if len(byColumn) > 0 {
+		dest = append(dest, &by)
+	}
+	if len(r.Config.Timestamp) > 0 {
+		dest = append(dest, &timestamp)
+	}
+	err := tx.QueryRowContext(ctx, query, key, r.Table).Scan(dest...)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var list []Revision
+	if len(revisions.String) > 0 {
+		err = json.Unmarshal([]byte(revisions.String), &list)
+		if err != nil {
+			return nil, err
+		}
+	}
+	revision := Revision{Revision: len(list) + 1, By: by.String}
+	if len(value.String) > 0 {
+		revision.Value, err = decodeJsonObject(value.String)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if timestamp.Valid {
+		t := timestamp.Time
+		revision.Timestamp = &t
+	}
+	return append(list, revision), nil
+}
+
+func toNullJson(v interface{}) (interface{}, error) {
+	if isNilJson(v) {
+		return nil, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// getCurrent returns the row of keys by the json names of the columns of the model, with the json values of their fields, so that it compares with a staged origin; the other columns are left out.
+func getCurrent(ctx context.Context, tx *sql.Tx, table string, columns map[string]string, types map[string]reflect.Type, idNames []string, keys map[string]interface{}, buildParam func(int) string) (map[string]interface{}, error) {
+	where, args := buildWhere(columns, idNames, keys, 1, buildParam)
+	rows, err := tx.QueryContext(ctx, "select * from "+table+" where "+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	jsonNames := make(map[string]string)
+	for jsonName, column := range columns {
+		jsonNames[strings.ToLower(column)] = jsonName
+	}
+	if !rows.Next() {
+		return nil, rows.Err()
+	}
+	vals := make([]interface{}, len(cols))
+	for i := range vals {
+		vals[i] = new(interface{})
+	}
+	err = rows.Scan(vals...)
+	if err != nil {
+		return nil, err
+	}
+	current := make(map[string]interface{})
+	for i, col := range cols {
+		name, ok := jsonNames[strings.ToLower(col)]
+		if !ok {
+			if len(columns) > 0 {
+				continue
+			}
+			name = col
+		}
+		v := *(vals[i].(*interface{}))
+		if b, ok := v.([]byte); ok {
+			v = string(b)
+		}
+		current[name] = toJsonType(v, types[name])
+	}
+	return current, nil
+}
+
+func getJsonTypes(modelType reflect.Type) map[string]reflect.Type {
+	types := make(map[string]reflect.Type)
+	if modelType == nil {
+		return types
+	}
+	if modelType.Kind() == reflect.Ptr {
+		modelType = modelType.Elem()
+	}
+	for i := 0; i < modelType.NumField(); i++ {
+		field := modelType.Field(i)
+		jsonName := strings.Split(field.Tag.Get("json"), ",")[0]
+		if len(jsonName) > 0 && jsonName != "-" {
+			types[jsonName] = field.Type
+		}
+	}
+	return types
+}
+
+// toJsonType converts a scanned value to the json type of its field, since drivers return a bool as a number, e.g. a tinyint of MySQL, and a decimal as a string.
+func toJsonType(v interface{}, t reflect.Type) interface{} {
+	if v == nil || t == nil {
+		return v
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		switch x := v.(type) {
+		case int64:
+			return x != 0
+		case string:
+			if b, err := strconv.ParseBool(x); err == nil {
+				return b
+			}
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+		if s, ok := v.(string); ok {
+			if _, err := strconv.ParseFloat(s, 64); err == nil {
+				return json.Number(s)
+			}
+		}
+	}
+	return v
+}
@@ -0,0 +1,62 @@
+package diff_test
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRevertStagesTheInverseChangeForAnotherChecker(t *testing.T) {
+	f := newFixture(t, "insert into items values('a-b', 'x-y', 'A')")
+	ctx := context.Background()
+	submitter := f.Submitter()
+	approver := f.Approver()
+	id := map[string]interface{}{"id": "a-b", "code": "x-y"}
+
+	f.Submit(t, id, "B")
+	f.User = "checker"
+	if status, err := approver.Approve(ctx, id); err != nil || status != approver.Status.Success {
+		t.Fatalf("approve = %d %v", status, err)
+	}
+	f.User = "maker"
+	if status, err := submitter.Revert(ctx, "h1"); err != nil || status != submitter.Status.Success {
+		t.Fatalf("revert = %d %v", status, err)
+	}
+	if name := getName(t, f.DB); name != "B" {
+		t.Errorf("name = %q, the revert must wait for a checker", name)
+	}
+	f.User = "checker"
+	if status, err := approver.Approve(ctx, id); err != nil || status != approver.Status.Success {
+		t.Fatalf("approve revert = %d %v", status, err)
+	}
+	if name := getName(t, f.DB); name != "A" {
+		t.Errorf("name = %q, want %q", name, "A")
+	}
+}
+
+func TestOnlyTheMakerMayResubmitAStagedChange(t *testing.T) {
+	f := newFixture(t, "insert into items values('a', 'x', 'A')")
+	ctx := context.Background()
+	submitter := f.Submitter()
+	id := map[string]interface{}{"id": "a", "code": "x"}
+	f.Submit(t, id, "B")
+	f.User = "other"
+	if status, err := submitter.Submit(ctx, id, map[string]interface{}{"name": "C"}); err != nil || status != submitter.Status.Forbidden {
+		t.Errorf("resubmit by another user = %d %v, want Forbidden", status, err)
+	}
+	f.User = "maker"
+	if status, err := submitter.Submit(ctx, id, map[string]interface{}{"name": "C"}); err != nil || status != submitter.Status.Success {
+		t.Errorf("resubmit by the maker = %d %v", status, err)
+	}
+}
+
+func TestSubmitRefusesUnknownFields(t *testing.T) {
+	f := newFixture(t, "insert into items values('a', 'x', 'A')")
+	submitter := f.Submitter()
+	id := map[string]interface{}{"id": "a", "code": "x"}
+	if status, err := submitter.Submit(context.Background(), id, map[string]interface{}{"name = 'C' --": 1}); err == nil || status != submitter.Status.Error {
+		t.Errorf("submit = %d %v, want Error", status, err)
+	}
+	if count := f.Count(t, "pending"); count != 0 {
+		t.Errorf("staged = %d, want none", count)
+	}
+}
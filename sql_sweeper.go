@@ -0,0 +1,177 @@
+package diff
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const (
+	ReasonExpired = "expired"
+	SystemUser    = "system"
+)
+
+// SqlSweeper auto-rejects the staged changes which are older than the TTL of their resource; the key of TTL is the Table of the approver, and a resource without TTL never expires.
+// An expired change is written to History with System as approver, whether or not the approver keeps rejected changes, and sent to Notifier as a ChangeRejected event.
+type SqlSweeper struct {
+	Approvers []*SqlApprover
+	TTL       map[string]time.Duration
+	System    string
+	Limit     int64
+	Log       func(ctx context.Context, resource string, action string, success bool, desc string) error
+	Notifier  Notifier
+	// Error receives the error of each sweep of Run
+	Error func(context.Context, string)
+}
+
+func NewSqlSweeper(approvers []*SqlApprover, ttl map[string]time.Duration, writeLog func(context.Context, string, string, bool, string) error, options ...string) *SqlSweeper {
+	system := SystemUser
+	if len(options) > 0 && len(options[0]) > 0 {
+		system = options[0]
+	}
+	return &SqlSweeper{Approvers: approvers, TTL: ttl, System: system, Log: writeLog}
+}
+
+// Sweep rejects the expired changes of all resources and returns how many were rejected; it goes on after a failed rejection and returns the first error.
+func (s SqlSweeper) Sweep(ctx context.Context) (int, error) {
+	count := 0
+	var result error
+	for _, approver := range s.Approvers {
+		ttl, ok := s.TTL[approver.Table]
+		if !ok || ttl <= 0 {
+			continue
+		}
+		n, err := s.sweep(ctx, approver, ttl)
+		count = count + n
+		if err != nil && result == nil {
+			result = err
+		}
+	}
+	return count, result
+}
+
+// Run sweeps every interval until ctx is done; the errors of the sweeps are reported to Error.
+func (s SqlSweeper) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := s.Sweep(ctx); err != nil && s.Error != nil {
+			s.Error(ctx, err.Error())
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s SqlSweeper) sweep(ctx context.Context, r *SqlApprover, ttl time.Duration) (int, error) {
+	if len(r.Config.Timestamp) == 0 {
+		err := fmt.Errorf("timestamp column is not configured for %s", r.Entity)
+		s.log(ctx, r.Table, false, err.Error())
+		return 0, err
+	}
+	keys, err := s.getExpired(ctx, r, time.Now().Add(-ttl))
+	if err != nil {
+		s.log(ctx, r.Table, false, err.Error())
+		return 0, err
+	}
+	ctx = WithReview(ctx, &Review{Reason: ReasonExpired})
+	count := 0
+	var result error
+	for _, key := range keys {
+		status, diff, err := s.expire(ctx, r, key.Id)
+		if err != nil {
+			s.log(ctx, r.Table, false, fmt.Sprintf("failed to reject expired change %v: %s", key.Key, err.Error()))
+			if result == nil {
+				result = err
+			}
+			continue
+		}
+		// the change may have been approved or rejected since it was listed
+		if status == r.Status.Success {
+			s.log(ctx, r.Table, true, fmt.Sprintf("rejected change %v pending for more than %s", key.Key, ttl))
+			count++
+			s.notify(ctx, r, key.Id, diff)
+		}
+	}
+	return count, result
+}
+
+// expire rejects the expired change and returns it as it was before the rejection.
+func (s SqlSweeper) expire(ctx context.Context, r *SqlApprover, id interface{}) (int, *DiffModel, error) {
+	key, _, err := buildKeys(r.KeyBuilder, r.IdNames, id)
+	if err != nil {
+		return r.Status.Error, nil, err
+	}
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return r.Status.Error, nil, err
+	}
+	diff, err := r.getStagedDiff(ctx, tx, key)
+	if err != nil {
+		tx.Rollback()
+		return r.Status.Error, nil, err
+	}
+	status, err := r.rejectBy(ctx, tx, id, s.System, true)
+	if err != nil || status != r.Status.Success {
+		tx.Rollback()
+		return status, nil, err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return r.Status.Error, nil, err
+	}
+	return status, diff, nil
+}
+
+func (s SqlSweeper) notify(ctx context.Context, r *SqlApprover, id interface{}, diff *DiffModel) {
+	if s.Notifier == nil || diff == nil {
+		return
+	}
+	event := r.buildReviewEvent(ChangeRejected, id, *diff, s.System)
+	event.Reason = ReasonExpired
+	if err := s.Notifier.Notify(ctx, event); err != nil {
+		s.log(ctx, r.Table, false, err.Error())
+	}
+}
+
+type expiredKey struct {
+	Key interface{}
+	Id  interface{}
+}
+
+func (s SqlSweeper) getExpired(ctx context.Context, r *SqlApprover, before time.Time) ([]expiredKey, error) {
+	query := fmt.Sprintf("select %s, %s, %s from %s where %s = %s and %s < %s order by %s", r.Config.Id, r.Config.Origin, r.Config.Value, r.Entity,
+		r.EntityType, r.BuildParam(1),
+		r.Config.Timestamp, r.BuildParam(2), r.Config.Timestamp)
+	query = query + buildPaging(r.Driver, 1, s.Limit)
+	rows, err := r.DB.QueryContext(ctx, query, r.Table, before)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	keys := make([]expiredKey, 0)
+	for rows.Next() {
+		var key string
+		var origin, value sql.NullString
+		if err := rows.Scan(&key, &origin, &value); err != nil {
+			return nil, err
+		}
+		id, err := getId(key, r.IdNames, origin.String, value.String)
+		if err != nil {
+			s.log(ctx, r.Table, false, err.Error())
+			continue
+		}
+		keys = append(keys, expiredKey{Key: key, Id: id})
+	}
+	return keys, rows.Err()
+}
+
+func (s SqlSweeper) log(ctx context.Context, resource string, success bool, desc string) {
+	if s.Log != nil {
+		s.Log(ctx, resource, "expire", success, desc)
+	}
+}
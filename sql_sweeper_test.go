@@ -0,0 +1,62 @@
+package diff_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	d "github.com/core-go/diff"
+)
+
+func TestSweeperRejectsExpiredChangesWithTheirApprovals(t *testing.T) {
+	f := newFixture(t,
+		"create table approvals(id text, entitytype text, stage text, approved_by text)",
+		"insert into items values('a-b', 'x', 'A')",
+	)
+	ctx := context.Background()
+	approver := f.Approver()
+	reader := f.DiffReader()
+	workflow := d.NewSqlApprovalWorkflow(reader, approver, "approvals", d.ApprovalConfig{}, d.Quorum(2), nil)
+	id := map[string]interface{}{"id": "a-b", "code": "x"}
+	f.Submit(t, id, "B")
+	f.User = "c1"
+	if status, err := workflow.Approve(ctx, id); err != nil || status != approver.Status.Pending {
+		t.Fatalf("approve = %d %v", status, err)
+	}
+
+	events := make(eventChannel, 1)
+	sweeper := d.NewSqlSweeper([]*d.SqlApprover{workflow.Approver}, map[string]time.Duration{"items": time.Nanosecond}, nil)
+	sweeper.Notifier = events
+	count, err := sweeper.Sweep(ctx)
+	if err != nil || count != 1 {
+		t.Fatalf("sweep = %d %v", count, err)
+	}
+	if approvals, staged := f.Count(t, "approvals"), f.Count(t, "pending"); approvals != 0 || staged != 0 {
+		t.Errorf("approvals = %d, staged = %d after the change expired, want none", approvals, staged)
+	}
+	select {
+	case event := <-events:
+		if event.Type != d.ChangeRejected || event.Reason != d.ReasonExpired || event.ApprovedBy != d.SystemUser {
+			t.Errorf("event = %+v", event)
+		}
+	default:
+		t.Error("no event was sent for the expired change")
+	}
+}
+
+func TestSweeperRunReportsErrors(t *testing.T) {
+	f := newFixture(t)
+	f.Config = d.DiffConfig{}
+	approver := f.Approver()
+	sweeper := d.NewSqlSweeper([]*d.SqlApprover{approver}, map[string]time.Duration{"items": time.Hour}, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	var errs []string
+	sweeper.Error = func(ctx context.Context, err string) {
+		errs = append(errs, err)
+		cancel()
+	}
+	sweeper.Run(ctx, time.Hour)
+	if len(errs) != 1 {
+		t.Errorf("errors = %v, want the missing timestamp column", errs)
+	}
+}
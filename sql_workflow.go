@@ -0,0 +1,243 @@
+package diff
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type SqlApprovalWorkflow struct {
+	DiffService DiffService
+	Approver    *SqlApprover
+	Table       string
+	Config      ApprovalConfig
+	Stages      []ApprovalStage
+	Authorize   func(ctx context.Context, stage string) bool
+}
+
+func NewSqlApprovalWorkflow(diffService DiffService, approver *SqlApprover, table string, config ApprovalConfig, stages []ApprovalStage, authorize func(context.Context, string) bool) *SqlApprovalWorkflow {
+	if len(config.Id) == 0 {
+		config.Id = approver.Config.Id
+	}
+	if len(config.EntityType) == 0 {
+		config.EntityType = approver.EntityType
+	}
+	if len(config.Stage) == 0 {
+		config.Stage = "stage"
+	}
+	if len(config.ApprovedBy) == 0 {
+		config.ApprovedBy = "approved_by"
+	}
+	// the workflow approves with a copy of approver which clears its approvals, so that approver is left as it is
+	a := *approver
+	w := &SqlApprovalWorkflow{DiffService: diffService, Approver: &a, Table: table, Config: config, Stages: stages, Authorize: authorize}
+	if a.ClearApprovals == nil {
+		a.ClearApprovals = w.ClearApprovals
+	}
+	return w
+}
+
+func (w SqlApprovalWorkflow) GetStatus() StatusConfig {
+	return w.Approver.Status
+}
+
+func (w SqlApprovalWorkflow) Diff(ctx context.Context, id interface{}) (*DiffModel, error) {
+	result, err := w.DiffService.Diff(ctx, id)
+	if err != nil || result == nil {
+		return result, err
+	}
+	progress, err := w.Progress(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	result.Progress = progress
+	return result, nil
+}
+
+func (w SqlApprovalWorkflow) Progress(ctx context.Context, id interface{}) (*ApprovalProgress, error) {
+	r := w.Approver
+	key, _, err := buildKeys(r.KeyBuilder, r.IdNames, id)
+	if err != nil {
+		return nil, err
+	}
+	by, err := w.getApprovals(ctx, r.DB, key)
+	if err != nil {
+		return nil, err
+	}
+	progress := BuildProgress(w.Stages, by)
+	return &progress, nil
+}
+
+func (w SqlApprovalWorkflow) Approve(ctx context.Context, id interface{}) (int, error) {
+	r := w.Approver
+	key, _, err := buildKeys(r.KeyBuilder, r.IdNames, id)
+	if err != nil {
+		return r.Status.Error, err
+	}
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return r.Status.Error, err
+	}
+	status, err := w.approve(ctx, tx, id, key)
+	if err != nil || (status != r.Status.Success && status != r.Status.Pending) {
+		tx.Rollback()
+		return status, err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return r.Status.Error, err
+	}
+	return status, nil
+}
+
+func (w SqlApprovalWorkflow) approve(ctx context.Context, tx *sql.Tx, id interface{}, key interface{}) (int, error) {
+	r := w.Approver
+	err := w.lock(ctx, tx, key)
+	if err != nil {
+		return r.Status.Error, err
+	}
+	diff, err := r.getStagedDiff(ctx, tx, key)
+	if err != nil {
+		return r.Status.Error, err
+	}
+	if diff == nil {
+		return r.Status.NotFound, nil
+	}
+	// a change sent back to its author collects no approval until it is resubmitted
+	if diff.State == StateChangesRequested {
+		return r.Status.Forbidden, nil
+	}
+	user := ""
+	if r.GetUser != nil {
+		user = r.GetUser(ctx)
+	}
+	if len(user) == 0 || (!r.AllowSelfApproval && user == diff.By) {
+		return r.Status.Forbidden, nil
+	}
+	by, err := w.getApprovals(ctx, tx, key)
+	if err != nil {
+		return r.Status.Error, err
+	}
+	if find(by, user) {
+		return r.Status.Forbidden, nil
+	}
+	progress := BuildProgress(w.Stages, by)
+	if w.Authorize != nil && !w.Authorize(ctx, progress.Stage) {
+		return r.Status.Forbidden, nil
+	}
+	if progress.Approved+1 < progress.Required {
+		err = w.insertApproval(ctx, tx, key, progress.Stage, user)
+		if err != nil {
+			return r.Status.Error, err
+		}
+		return r.Status.Pending, nil
+	}
+	status, err := r.approve(ctx, tx, id)
+	if err != nil || status != r.Status.Success {
+		return status, err
+	}
+	_, err = w.deleteApprovals(ctx, tx, key)
+	if err != nil {
+		return r.Status.Error, err
+	}
+	return status, nil
+}
+
+func (w SqlApprovalWorkflow) Reject(ctx context.Context, id interface{}) (int, error) {
+	r := w.Approver
+	key, _, err := buildKeys(r.KeyBuilder, r.IdNames, id)
+	if err != nil {
+		return r.Status.Error, err
+	}
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return r.Status.Error, err
+	}
+	status, err := r.reject(ctx, tx, id)
+	if err != nil || status != r.Status.Success {
+		tx.Rollback()
+		return status, err
+	}
+	_, err = w.deleteApprovals(ctx, tx, key)
+	if err != nil {
+		tx.Rollback()
+		return r.Status.Error, err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return r.Status.Error, err
+	}
+	return status, nil
+}
+
+// lock updates the staged change to lock it until tx ends, so that approvers of the same change count its approvals one after another.
+func (w SqlApprovalWorkflow) lock(ctx context.Context, tx *sql.Tx, key interface{}) error {
+	r := w.Approver
+	query := fmt.Sprintf("update %s set %s = %s where %s = %s and %s = %s", r.Entity,
+		r.Config.Id, r.Config.Id,
+		r.Config.Id, r.BuildParam(1),
+		r.EntityType, r.BuildParam(2))
+	_, err := tx.ExecContext(ctx, query, key, r.Table)
+	return err
+}
+
+// queryer is a *sql.DB or a *sql.Tx.
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+func (w SqlApprovalWorkflow) getApprovals(ctx context.Context, db queryer, key interface{}) ([]string, error) {
+	buildParam := w.Approver.BuildParam
+	query := fmt.Sprintf("select %s from %s where %s = %s and %s = %s", w.Config.ApprovedBy, w.Table,
+		w.Config.Id, buildParam(1),
+		w.Config.EntityType, buildParam(2))
+	if len(w.Config.Timestamp) > 0 {
+		query = query + " order by " + w.Config.Timestamp
+	}
+	rows, err := db.QueryContext(ctx, query, key, w.Approver.Table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	by := make([]string, 0)
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			return nil, err
+		}
+		by = append(by, s)
+	}
+	return by, rows.Err()
+}
+
+func (w SqlApprovalWorkflow) insertApproval(ctx context.Context, tx *sql.Tx, key interface{}, stage string, approvedBy string) error {
+	columns := []string{w.Config.Id, w.Config.EntityType, w.Config.Stage, w.Config.ApprovedBy}
+	values := []interface{}{key, w.Approver.Table, stage, approvedBy}
+	if len(w.Config.Timestamp) > 0 {
+		columns = append(columns, w.Config.Timestamp)
+		values = append(values, time.Now())
+	}
+	query := fmt.Sprintf("insert into %s(%s) values (%s)", w.Table, strings.Join(columns, ","), buildParameters(len(columns), w.Approver.BuildParam))
+	_, err := tx.ExecContext(ctx, query, values...)
+	return err
+}
+
+// ClearApprovals deletes the approvals given to a staged change; the change must collect them again after it is submitted again, sent back or rejected.
+func (w SqlApprovalWorkflow) ClearApprovals(ctx context.Context, tx *sql.Tx, key interface{}) error {
+	_, err := w.deleteApprovals(ctx, tx, key)
+	return err
+}
+
+func (w SqlApprovalWorkflow) deleteApprovals(ctx context.Context, tx *sql.Tx, key interface{}) (int64, error) {
+	buildParam := w.Approver.BuildParam
+	query := fmt.Sprintf("delete from %s where %s = %s and %s = %s", w.Table,
+		w.Config.Id, buildParam(1),
+		w.Config.EntityType, buildParam(2))
+	res, err := tx.ExecContext(ctx, query, key, w.Approver.Table)
+	if err != nil {
+		return -1, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,81 @@
+package diff_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	d "github.com/core-go/diff"
+)
+
+func TestResubmitAfterApprovalClearsApprovals(t *testing.T) {
+	f := newFixture(t,
+		"create table approvals(id text, entitytype text, stage text, approved_by text)",
+		"insert into items values('a', 'x', 'A')",
+	)
+	ctx := context.Background()
+	submitter := f.Submitter()
+	approver := f.Approver()
+	workflow := d.NewSqlApprovalWorkflow(f.DiffReader(), approver, "approvals", d.ApprovalConfig{}, d.Quorum(2), nil)
+	submitter.ClearApprovals = workflow.ClearApprovals
+	id := map[string]interface{}{"id": "a", "code": "x"}
+
+	f.Submit(t, id, "B")
+	f.User = "c1"
+	if status, err := workflow.Approve(ctx, id); err != nil || status != approver.Status.Pending {
+		t.Fatalf("first approval = %d %v, want Pending", status, err)
+	}
+	f.User = "maker"
+	if status, err := submitter.Submit(ctx, id, map[string]interface{}{"name": "C"}); err != nil || status != submitter.Status.Success {
+		t.Fatalf("resubmit = %d %v", status, err)
+	}
+	f.User = "c2"
+	if status, err := workflow.Approve(ctx, id); err != nil || status != approver.Status.Pending {
+		t.Fatalf("approval of the resubmitted change = %d %v, want Pending", status, err)
+	}
+	progress, err := workflow.Progress(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if progress.Approved != 1 || !reflect.DeepEqual(progress.By, []string{"c2"}) {
+		t.Errorf("progress = %+v, want only the approval of c2", progress)
+	}
+	if name := getName(t, f.DB); name != "A" {
+		t.Errorf("name = %q, the change must still be pending", name)
+	}
+}
+
+func TestWorkflowRefusesApprovalsOfAChangeSentBack(t *testing.T) {
+	f := newFixture(t,
+		"alter table pending add column state text",
+		"create table approvals(id text, entitytype text, stage text, approved_by text)",
+		"insert into items values('a', 'x', 'A')",
+	)
+	f.Config.State = "state"
+	ctx := context.Background()
+	approver := f.Approver()
+	workflow := d.NewSqlApprovalWorkflow(f.DiffReader(), approver, "approvals", d.ApprovalConfig{}, d.Quorum(2), nil)
+	if approver.ClearApprovals != nil {
+		t.Error("the workflow must not change the approver it is given")
+	}
+	id := map[string]interface{}{"id": "a", "code": "x"}
+
+	f.Submit(t, id, "B")
+	f.User = "c1"
+	if status, err := workflow.Approve(ctx, id); err != nil || status != approver.Status.Pending {
+		t.Fatalf("approve = %d %v, want Pending", status, err)
+	}
+	if status, err := workflow.Approver.RequestChanges(ctx, id); err != nil || status != approver.Status.Success {
+		t.Fatalf("request changes = %d %v", status, err)
+	}
+	if count := f.Count(t, "approvals"); count != 0 {
+		t.Errorf("approvals = %d after the change was sent back, want none", count)
+	}
+	f.User = "c2"
+	if status, err := workflow.Approve(ctx, id); err != nil || status != approver.Status.Forbidden {
+		t.Errorf("approval of a change sent back = %d %v, want Forbidden", status, err)
+	}
+	if count := f.Count(t, "approvals"); count != 0 {
+		t.Errorf("approvals = %d, want none", count)
+	}
+}
@@ -1,5 +1,7 @@
 package diff
 
+import "net/http"
+
 type StatusDiffConfig struct {
 	Status *StatusConfig `yaml:"status" mapstructure:"status" json:"status" gorm:"column:status" bson:"status" dynamodbav:"status" firestore:"status"`
 	Config DiffConfig    `yaml:"config" mapstructure:"config" json:"config" gorm:"column:config" bson:"config" dynamodbav:"config" firestore:"config"`
@@ -10,22 +12,61 @@ type StatusConfig struct {
 	Success      int `yaml:"success" mapstructure:"success" json:"success" gorm:"column:success" bson:"success" dynamodbav:"success" firestore:"success"`
 	VersionError int `yaml:"version_error" mapstructure:"version_error" json:"versionError" gorm:"column:versionerror" bson:"versionError" dynamodbav:"versionError" firestore:"versionError"`
 	Error        int `yaml:"error" mapstructure:"error" json:"error" gorm:"column:error" bson:"error" dynamodbav:"error" firestore:"error"`
+	Forbidden    int `yaml:"forbidden" mapstructure:"forbidden" json:"forbidden" gorm:"column:forbidden" bson:"forbidden" dynamodbav:"forbidden" firestore:"forbidden"`
+	Pending      int `yaml:"pending" mapstructure:"pending" json:"pending" gorm:"column:pending" bson:"pending" dynamodbav:"pending" firestore:"pending"`
+}
+
+// StatusService is implemented by the services with a status config, so that handlers map the codes the service returns.
+type StatusService interface {
+	GetStatus() StatusConfig
+}
+
+// GetServiceStatus returns the status config of service, or the default one if service does not implement StatusService.
+func GetServiceStatus(service interface{}) StatusConfig {
+	if s, ok := service.(StatusService); ok {
+		return s.GetStatus()
+	}
+	return InitializeStatus(nil)
 }
 
+// InitializeStatus keeps the codes which are set and gives each unset code its default, or the next code no other status uses.
 func InitializeStatus(status *StatusConfig) StatusConfig {
 	var s StatusConfig
 	if status != nil {
-		s.NotFound = status.NotFound
-		s.Success = status.Success
-		s.VersionError = status.VersionError
-		s.Error = status.Error
+		s = *status
 	}
-	if s.NotFound == 0 && s.Success == 0 && s.VersionError == 0 && s.Error == 0 {
-		s.Success = 1
+	s.Success = getCode(s, s.Success, 1)
+	s.VersionError = getCode(s, s.VersionError, 2)
+	s.Forbidden = getCode(s, s.Forbidden, 3)
+	s.Error = getCode(s, s.Error, 4)
+	s.Pending = getCode(s, s.Pending, 5)
+	return s
+}
+
+func getCode(s StatusConfig, code int, defaultCode int) int {
+	if code != 0 {
+		return code
 	}
-	if s.NotFound == 0 && s.Success == 1 && s.VersionError == 0 && s.Error == 0 {
-		s.VersionError = 2
-		s.Error = 4
+	for isUsed(s, defaultCode) {
+		defaultCode++
+	}
+	return defaultCode
+}
+
+func isUsed(s StatusConfig, code int) bool {
+	return code == s.NotFound || code == s.Success || code == s.VersionError || code == s.Error || code == s.Forbidden || code == s.Pending
+}
+
+// GetReviewCode maps the result of Approve, Reject, RequestChanges or Comment to an HTTP status code: NotFound to 404, Forbidden to 403, VersionError to 409 and the others to 200.
+func GetReviewCode(status StatusConfig, result int) int {
+	switch result {
+	case status.NotFound:
+		return http.StatusNotFound
+	case status.Forbidden:
+		return http.StatusForbidden
+	case status.VersionError:
+		return http.StatusConflict
+	default:
+		return http.StatusOK
 	}
-	return s
 }
@@ -0,0 +1,26 @@
+package diff_test
+
+import (
+	"testing"
+
+	d "github.com/core-go/diff"
+)
+
+func TestInitializeStatusDefaultsEachUnsetCode(t *testing.T) {
+	s := d.InitializeStatus(nil)
+	if s != (d.StatusConfig{NotFound: 0, Success: 1, VersionError: 2, Forbidden: 3, Error: 4, Pending: 5}) {
+		t.Errorf("default status = %+v", s)
+	}
+	s = d.InitializeStatus(&d.StatusConfig{NotFound: 0, Success: 200, VersionError: 409, Error: 500})
+	if s.Forbidden == s.NotFound || s.Pending == s.NotFound || s.Forbidden == s.Pending {
+		t.Errorf("unset codes collide: %+v", s)
+	}
+	s = d.InitializeStatus(&d.StatusConfig{NotFound: 3, Error: 1})
+	codes := map[int]bool{}
+	for _, code := range []int{s.NotFound, s.Success, s.VersionError, s.Error, s.Forbidden, s.Pending} {
+		if codes[code] {
+			t.Fatalf("codes collide: %+v", s)
+		}
+		codes[code] = true
+	}
+}
@@ -0,0 +1,77 @@
+package diff
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+)
+
+type SubmitHandler struct {
+	SubmitService SubmitService
+	Keys          []string
+	ModelType     reflect.Type
+	Error         func(context.Context, string)
+	Indexes       map[string]int
+	Fields        map[string]bool
+	Offset        int
+	Log           func(ctx context.Context, resource string, action string, success bool, desc string) error
+	Resource      string
+	Action        string
+}
+
+func NewSubmitHandler(submitService SubmitService, modelType reflect.Type, logError func(context.Context, string), writeLog func(context.Context, string, string, bool, string) error, options ...int) *SubmitHandler {
+	return NewSubmitHandlerWithKeys(submitService, nil, modelType, logError, writeLog, options...)
+}
+func NewSubmitHandlerWithKeys(submitService SubmitService, keys []string, modelType reflect.Type, logError func(context.Context, string), writeLog func(context.Context, string, string, bool, string) error, options ...int) *SubmitHandler {
+	offset := 1
+	if len(options) > 0 && options[0] >= 0 {
+		offset = options[0]
+	}
+	if keys == nil || len(keys) == 0 {
+		keys = GetJsonPrimaryKeys(modelType)
+	}
+	indexes := GetIndexes(modelType)
+	fields := GetJsonFields(modelType)
+	resource := BuildResourceName(modelType.Name())
+	return &SubmitHandler{Log: writeLog, SubmitService: submitService, ModelType: modelType, Keys: keys, Indexes: indexes, Fields: fields, Offset: offset, Error: logError, Resource: resource, Action: "submit"}
+}
+
+func (c *SubmitHandler) Submit(w http.ResponseWriter, r *http.Request) {
+	id, er1 := BuildId(r, c.ModelType, c.Keys, c.Indexes, c.Offset)
+	if er1 != nil {
+		http.Error(w, er1.Error(), http.StatusBadRequest)
+		return
+	}
+	var patch map[string]interface{}
+	er2 := json.NewDecoder(r.Body).Decode(&patch)
+	if er2 != nil {
+		http.Error(w, er2.Error(), http.StatusBadRequest)
+		return
+	}
+	er3 := CheckFields(c.Fields, patch)
+	if er3 != nil {
+		http.Error(w, er3.Error(), http.StatusBadRequest)
+		return
+	}
+	result, er4 := c.SubmitService.Submit(r.Context(), id, patch)
+	if er4 != nil {
+		handleError(w, r, http.StatusInternalServerError, internalServerError, c.Error, c.Resource, c.Action, er4, c.Log)
+	} else {
+		succeed(w, r, http.StatusOK, result, c.Log, c.Resource, c.Action)
+	}
+}
+
+func (c *SubmitHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, er1 := BuildId(r, c.ModelType, c.Keys, c.Indexes, c.Offset)
+	if er1 != nil {
+		http.Error(w, er1.Error(), http.StatusBadRequest)
+		return
+	}
+	result, er2 := c.SubmitService.Delete(r.Context(), id)
+	if er2 != nil {
+		handleError(w, r, http.StatusInternalServerError, internalServerError, c.Error, c.Resource, "delete", er2, c.Log)
+	} else {
+		succeed(w, r, http.StatusOK, result, c.Log, c.Resource, "delete")
+	}
+}
@@ -0,0 +1,30 @@
+package diff_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	d "github.com/core-go/diff"
+)
+
+func TestSubmitHandlerRefusesUnknownFields(t *testing.T) {
+	store := d.NewMemoryStore("users", []string{"id"}, nil, d.NewDefaultKeyBuilder(), nil)
+	handler := d.NewSubmitHandler(store, reflect.TypeOf(User{}), nil, nil)
+	tests := []struct {
+		body string
+		code int
+	}{
+		{`{"name":"B"}`, http.StatusOK},
+		{`{"name":"B","nick":"b"}`, http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		handler.Submit(w, httptest.NewRequest(http.MethodPost, "/users/u1/submit", strings.NewReader(test.body)))
+		if w.Code != test.code {
+			t.Errorf("submit %s: %d, want %d", test.body, w.Code, test.code)
+		}
+	}
+}
@@ -0,0 +1,12 @@
+package diff
+
+import "context"
+
+type SubmitService interface {
+	Submit(ctx context.Context, id interface{}, patch map[string]interface{}) (int, error)
+	Delete(ctx context.Context, id interface{}) (int, error)
+}
+
+type RevertService interface {
+	Revert(ctx context.Context, historyId string) (int, error)
+}
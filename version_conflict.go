@@ -0,0 +1,53 @@
+package diff
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+type VersionConflict struct {
+	Id      interface{} `yaml:"id" mapstructure:"id" json:"id,omitempty" gorm:"column:id" bson:"_id,omitempty" dynamodbav:"id,omitempty" firestore:"id,omitempty"`
+	Origin  interface{} `yaml:"origin" mapstructure:"origin" json:"origin,omitempty" gorm:"column:origin" bson:"origin,omitempty" dynamodbav:"origin,omitempty" firestore:"origin,omitempty"`
+	Current interface{} `yaml:"current" mapstructure:"current" json:"current,omitempty" gorm:"column:current" bson:"current,omitempty" dynamodbav:"current,omitempty" firestore:"current,omitempty"`
+	Value   interface{} `yaml:"value" mapstructure:"value" json:"value,omitempty" gorm:"column:value" bson:"value,omitempty" dynamodbav:"value,omitempty" firestore:"value,omitempty"`
+}
+
+func (e *VersionConflict) Error() string {
+	return "the data has been modified since the change was made"
+}
+
+// IsStale reports whether current has moved on from origin: by the version field when versionName is given, otherwise by the fields of origin.
+func IsStale(origin map[string]interface{}, current map[string]interface{}, versionName string) bool {
+	if current == nil || origin == nil {
+		return current != nil || origin != nil
+	}
+	c := normalizeJson(current)
+	if len(versionName) > 0 {
+		return !equalValues(origin[versionName], c[versionName])
+	}
+	return len(BuildChanges(origin, pick(c, origin))) > 0
+}
+
+func pick(m map[string]interface{}, fields map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{})
+	for k := range fields {
+		if v, ok := m[k]; ok {
+			result[k] = v
+		}
+	}
+	return result
+}
+
+func normalizeJson(m map[string]interface{}) map[string]interface{} {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return m
+	}
+	decoder := json.NewDecoder(strings.NewReader(string(b)))
+	decoder.UseNumber()
+	var result map[string]interface{}
+	if err := decoder.Decode(&result); err != nil {
+		return m
+	}
+	return result
+}
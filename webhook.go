@@ -0,0 +1,141 @@
+package diff
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	HeaderSignature = "X-Signature-256"
+	HeaderTimestamp = "X-Signature-Timestamp"
+	HeaderEventType = "X-Event-Type"
+)
+
+type WebhookEndpoint struct {
+	Url    string   `yaml:"url" mapstructure:"url" json:"url,omitempty" gorm:"column:url" bson:"url,omitempty" dynamodbav:"url,omitempty" firestore:"url,omitempty"`
+	Secret string   `yaml:"secret" mapstructure:"secret" json:"secret,omitempty" gorm:"column:secret" bson:"secret,omitempty" dynamodbav:"secret,omitempty" firestore:"secret,omitempty"`
+	Events []string `yaml:"events" mapstructure:"events" json:"events,omitempty" gorm:"column:events" bson:"events,omitempty" dynamodbav:"events,omitempty" firestore:"events,omitempty"`
+}
+
+type DeadLetterWriter interface {
+	Write(ctx context.Context, url string, event Event, attempts int, cause error) error
+}
+
+// WebhookNotifier posts events as json to the endpoints subscribed to the event type; an endpoint without Events receives all events.
+// Failed deliveries are retried Retries times, waiting Backoff and doubling it after each attempt, then written to DeadLetter.
+type WebhookNotifier struct {
+	Endpoints  []WebhookEndpoint
+	Client     *http.Client
+	Retries    int
+	Backoff    time.Duration
+	DeadLetter DeadLetterWriter
+}
+
+func NewWebhookNotifier(endpoints []WebhookEndpoint, client *http.Client, retries int, backoff time.Duration, deadLetter DeadLetterWriter) *WebhookNotifier {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if backoff <= 0 {
+		backoff = time.Second
+	}
+	return &WebhookNotifier{Endpoints: endpoints, Client: client, Retries: retries, Backoff: backoff, DeadLetter: deadLetter}
+}
+
+func (n WebhookNotifier) Notify(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	var result error
+	for _, endpoint := range n.Endpoints {
+		if len(endpoint.Events) > 0 && !find(endpoint.Events, event.Type) {
+			continue
+		}
+		attempts, er1 := n.deliver(ctx, endpoint, event.Type, body)
+		if er1 == nil {
+			continue
+		}
+		if n.DeadLetter != nil {
+			er1 = n.DeadLetter.Write(ctx, endpoint.Url, event, attempts, er1)
+		}
+		if er1 != nil && result == nil {
+			result = er1
+		}
+	}
+	return result
+}
+
+// Publish lets WebhookNotifier be the sink of SqlOutboxRelay.
+// A failed delivery written to DeadLetter is not an error, so the relay does not publish the event again; without DeadLetter,
+// the relay publishes the event again to all the endpoints, so the endpoints which received it already must ignore it by its Id.
+func (n WebhookNotifier) Publish(ctx context.Context, event Event) error {
+	return n.Notify(ctx, event)
+}
+
+func (n WebhookNotifier) deliver(ctx context.Context, endpoint WebhookEndpoint, eventType string, body []byte) (int, error) {
+	backoff := n.Backoff
+	attempts := 0
+	for {
+		attempts++
+		retry, err := n.post(ctx, endpoint, eventType, body)
+		if err == nil || !retry || attempts > n.Retries {
+			return attempts, err
+		}
+		select {
+		case <-ctx.Done():
+			return attempts, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = backoff * 2
+	}
+}
+
+// post returns whether a failed delivery may be retried: on network errors, 429 and 5xx.
+func (n WebhookNotifier) post(ctx context.Context, endpoint WebhookEndpoint, eventType string, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.Url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEventType, eventType)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	if len(endpoint.Secret) > 0 {
+		req.Header.Set(HeaderSignature, Sign(endpoint.Secret, timestamp, body))
+	}
+	res, err := n.Client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return false, nil
+	}
+	retry := res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= 500
+	return retry, fmt.Errorf("webhook %s responded %d", endpoint.Url, res.StatusCode)
+}
+
+// Sign returns "sha256=" and the hex HMAC-SHA256 of timestamp, "." and body, keyed by secret.
+func Sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature checks the signature headers of a webhook request against its body.
+func VerifySignature(secret string, header http.Header, body []byte) bool {
+	expected := Sign(secret, header.Get(HeaderTimestamp), body)
+	return hmac.Equal([]byte(expected), []byte(header.Get(HeaderSignature)))
+}
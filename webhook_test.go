@@ -0,0 +1,66 @@
+package diff_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	d "github.com/core-go/diff"
+)
+
+type deadLetters struct {
+	mu       sync.Mutex
+	entries  []string
+	attempts []int
+}
+
+func (l *deadLetters) Write(ctx context.Context, url string, event d.Event, attempts int, cause error) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, url)
+	l.attempts = append(l.attempts, attempts)
+	return nil
+}
+
+func TestWebhookSignsEventsAndDeadLettersFailedDeliveries(t *testing.T) {
+	var mu sync.Mutex
+	verified := 0
+	failures := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		body, _ := io.ReadAll(r.Body)
+		if r.URL.Path == "/fail" {
+			failures++
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if d.VerifySignature("secret", r.Header, body) && !d.VerifySignature("other", r.Header, body) && r.Header.Get(d.HeaderEventType) == d.ChangeApproved {
+			verified++
+		}
+	}))
+	defer server.Close()
+	deadLetter := &deadLetters{}
+	endpoints := []d.WebhookEndpoint{
+		{Url: server.URL + "/ok", Secret: "secret"},
+		{Url: server.URL + "/fail", Secret: "secret", Events: []string{d.ChangeApproved}},
+		{Url: server.URL + "/pending", Secret: "secret", Events: []string{d.ChangePending}},
+	}
+	notifier := d.NewWebhookNotifier(endpoints, nil, 2, time.Millisecond, deadLetter)
+	if err := notifier.Notify(context.Background(), d.Event{Type: d.ChangeApproved, EntityType: "users", EntityId: "u1"}); err != nil {
+		t.Fatal(err)
+	}
+	if verified != 1 {
+		t.Errorf("verified deliveries = %d, want 1", verified)
+	}
+	if failures != 3 {
+		t.Errorf("attempts to the failing endpoint = %d, want 3", failures)
+	}
+	if len(deadLetter.entries) != 1 || deadLetter.entries[0] != server.URL+"/fail" || deadLetter.attempts[0] != 3 {
+		t.Errorf("dead letters = %v %v, want the failing endpoint after 3 attempts", deadLetter.entries, deadLetter.attempts)
+	}
+}